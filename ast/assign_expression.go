@@ -0,0 +1,30 @@
+package ast
+
+import (
+	"bytes"
+	"interpreter/token"
+)
+
+// AssignExpression updates an existing binding, Target is either an *Identifier
+// or an *IndexExpression and Operator is one of =, +=, -=, *= or /=
+type AssignExpression struct {
+	Token    token.Token // the assignment operator token
+	Target   Expression
+	Operator string
+	Value    Expression
+}
+
+func (a *AssignExpression) expressionNode()      {}
+func (a *AssignExpression) TokenLiteral() string { return a.Token.Literal }
+func (a *AssignExpression) Pos() token.Position  { return a.Token.Pos }
+func (a *AssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(a.Target.String())
+	out.WriteString(" " + a.Operator + " ")
+	out.WriteString(a.Value.String())
+	out.WriteString(")")
+
+	return out.String()
+}
@@ -1,9 +1,12 @@
 package ast
 
+import "interpreter/token"
+
 // Type definitions
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position // position of the node's token in the source
 }
 
 // A statement is a piece of code that does NOT produce a value
@@ -0,0 +1,13 @@
+package ast
+
+import "interpreter/token"
+
+type Boolean struct {
+	Token token.Token // the TRUE or FALSE token
+	Value bool
+}
+
+func (b *Boolean) expressionNode()      {}
+func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
+func (b *Boolean) Pos() token.Position  { return b.Token.Pos }
+func (b *Boolean) String() string       { return b.Token.Literal }
@@ -9,6 +9,7 @@ type ExpressionStatement struct {
 
 func (l *ExpressionStatement) statementNode()       {}
 func (l *ExpressionStatement) TokenLiteral() string { return l.Token.Literal }
+func (l *ExpressionStatement) Pos() token.Position  { return l.Token.Pos }
 func (l *ExpressionStatement) String() string {
 	return l.Expression.String()
 }
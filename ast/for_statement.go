@@ -0,0 +1,31 @@
+package ast
+
+import (
+	"bytes"
+	"interpreter/token"
+)
+
+// ForStatement iterates over the elements of an array, the characters of a
+// string or the keys of a hash, binding each one to Variable in turn
+type ForStatement struct {
+	Token    token.Token // the FOR token
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fs *ForStatement) statementNode()       {}
+func (fs *ForStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *ForStatement) Pos() token.Position  { return fs.Token.Pos }
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for(")
+	out.WriteString(fs.Variable.String())
+	out.WriteString(" in ")
+	out.WriteString(fs.Iterable.String())
+	out.WriteString(") ")
+	out.WriteString(fs.Body.String())
+
+	return out.String()
+}
@@ -5,11 +5,19 @@ import "interpreter/token"
 type Identifier struct {
 	Token token.Token // the IDENT token
 	Value string
+	Type  *TypeName // annotation of a let name or a parameter, nil when there is none
+
+	// Symbol is set by the resolver, nil until then or when the name is undefined
+	Symbol *Symbol
 }
 
 func (i *Identifier) expressionNode()      {}
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
+func (i *Identifier) Pos() token.Position  { return i.Token.Pos }
 
 func (i *Identifier) String() string {
+	if i.Type != nil {
+		return i.Value + ": " + i.Type.String()
+	}
 	return i.Value
 }
@@ -0,0 +1,32 @@
+package ast
+
+import (
+	"bytes"
+	"interpreter/token"
+)
+
+type IfExpression struct {
+	Token       token.Token // the IF token
+	Condition   Expression
+	Consequence *BlockStatement
+	Alternative *BlockStatement // nil when there is no else
+}
+
+func (ie *IfExpression) expressionNode()      {}
+func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IfExpression) Pos() token.Position  { return ie.Token.Pos }
+func (ie *IfExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("if")
+	out.WriteString(ie.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(ie.Consequence.String())
+
+	if ie.Alternative != nil {
+		out.WriteString("else ")
+		out.WriteString(ie.Alternative.String())
+	}
+
+	return out.String()
+}
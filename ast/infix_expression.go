@@ -14,6 +14,7 @@ type InfixExpression struct {
 
 func (infx *InfixExpression) expressionNode()      {}
 func (infx *InfixExpression) TokenLiteral() string { return infx.Token.Literal }
+func (infx *InfixExpression) Pos() token.Position  { return infx.Token.Pos }
 func (infx *InfixExpression) String() string {
 	var out bytes.Buffer
 
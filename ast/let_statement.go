@@ -13,6 +13,7 @@ type LetStatement struct {
 
 func (l *LetStatement) statementNode()       {}
 func (l *LetStatement) TokenLiteral() string { return l.Token.Literal }
+func (l *LetStatement) Pos() token.Position  { return l.Token.Pos }
 
 func (l *LetStatement) String() string {
 	var out bytes.Buffer
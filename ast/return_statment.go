@@ -13,6 +13,7 @@ type ReturnStatement struct {
 func (i *ReturnStatement) expressionNode()      {}
 func (l *ReturnStatement) statementNode()       {}
 func (l *ReturnStatement) TokenLiteral() string { return l.Token.Literal }
+func (l *ReturnStatement) Pos() token.Position  { return l.Token.Pos }
 
 func (r *ReturnStatement) String() string {
 	var out bytes.Buffer
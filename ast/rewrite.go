@@ -0,0 +1,268 @@
+package ast
+
+// An ApplyFunc is called by Apply with a Cursor on the current node, its
+// result decides how the traversal goes on
+type ApplyFunc func(*Cursor) bool
+
+// Apply walks the tree under root like Walk, letting pre and post change it
+// through their Cursor, and returns the root which may have been replaced.
+//
+// pre is called on a node before its children and post after them, either
+// can be nil. When pre returns false the children and post are skipped for
+// that node, when post returns false the whole walk stops.
+func Apply(root Node, pre, post ApplyFunc) (result Node) {
+	defer func() {
+		if r := recover(); r != nil && r != abort {
+			panic(r)
+		}
+	}()
+
+	a := &application{pre: pre, post: post}
+	a.apply(nil, "Root", func(n Node) { root = n }, root)
+
+	return root
+}
+
+var abort = new(int) // panicked with to stop Apply early
+
+// A Cursor is the position of Apply in the tree: the current node, where it
+// sits in its parent, and ways to change it
+type Cursor struct {
+	parent Node
+	name   string
+	node   Node
+	set    func(Node)   // stores a replacement in the parent's field
+	list   *[]Statement // the statement list holding node, if any
+	iter   *iterator    // valid if list != nil
+}
+
+// Node returns the current node
+func (c *Cursor) Node() Node { return c.node }
+
+// Parent returns the node holding the current one
+func (c *Cursor) Parent() Node { return c.parent }
+
+// Name returns the field of Parent holding the current node
+func (c *Cursor) Name() string { return c.name }
+
+// Index returns where the current node is in the statement list of Parent,
+// or -1 when it is not in a list
+func (c *Cursor) Index() int {
+	if c.list != nil {
+		return c.iter.index
+	}
+	return -1
+}
+
+// Replace puts n in place of the current node, Apply does not walk n. It
+// panics when n does not fit the field, like a statement for an expression.
+func (c *Cursor) Replace(n Node) {
+	if c.list != nil {
+		(*c.list)[c.iter.index] = n.(Statement)
+	} else {
+		c.set(n)
+	}
+	c.node = n
+}
+
+// Delete removes the current statement from its list, it panics when the
+// node is not in a list
+func (c *Cursor) Delete() {
+	i := c.listIndex("Delete")
+
+	*c.list = append((*c.list)[:i], (*c.list)[i+1:]...)
+	c.iter.step--
+}
+
+// InsertAfter adds n after the current statement, it is not walked.
+// It panics when the node is not in a list.
+func (c *Cursor) InsertAfter(n Statement) {
+	i := c.listIndex("InsertAfter")
+
+	*c.list = append((*c.list)[:i+1], append([]Statement{n}, (*c.list)[i+1:]...)...)
+	c.iter.step++
+}
+
+// InsertBefore adds n before the current statement, it is not walked.
+// It panics when the node is not in a list.
+func (c *Cursor) InsertBefore(n Statement) {
+	i := c.listIndex("InsertBefore")
+
+	*c.list = append((*c.list)[:i], append([]Statement{n}, (*c.list)[i:]...)...)
+	c.iter.index++
+}
+
+func (c *Cursor) listIndex(op string) int {
+	if c.list == nil {
+		panic(op + " node not contained in statement list")
+	}
+	return c.iter.index
+}
+
+// iterator is the position in the statement list being applied, step says
+// how far to move once the current statement is done
+type iterator struct {
+	index, step int
+}
+
+type application struct {
+	pre, post ApplyFunc
+	cursor    Cursor
+	iter      iterator
+}
+
+func (a *application) apply(parent Node, name string, set func(Node), n Node) {
+	saved := a.cursor
+	a.cursor = Cursor{parent: parent, name: name, node: n, set: set}
+	a.visit()
+	a.cursor = saved
+}
+
+func (a *application) applyList(parent Node, name string, list *[]Statement) {
+	saved := a.iter
+	a.iter.index = 0
+
+	for a.iter.index < len(*list) {
+		a.iter.step = 1
+
+		if (*list)[a.iter.index] != nil {
+			savedCursor := a.cursor
+			a.cursor = Cursor{parent: parent, name: name, node: (*list)[a.iter.index], list: list, iter: &a.iter}
+			a.visit()
+			a.cursor = savedCursor
+		}
+
+		a.iter.index += a.iter.step
+	}
+
+	a.iter = saved
+}
+
+func (a *application) visit() {
+	if a.pre != nil && !a.pre(&a.cursor) {
+		return
+	}
+
+	switch n := a.cursor.node.(type) {
+	case *Program:
+		a.applyList(n, "Statements", &n.Statements)
+
+	case *Identifier:
+		if n.Type != nil {
+			a.apply(n, "Type", func(r Node) { n.Type = r.(*TypeName) }, n.Type)
+		}
+
+	case *LetStatement:
+		if n.Name != nil {
+			a.apply(n, "Name", func(r Node) { n.Name = r.(*Identifier) }, n.Name)
+		}
+		a.applyExpression(n, "Value", &n.Value)
+
+	case *ReturnStatement:
+		a.applyExpression(n, "ReturnValue", &n.ReturnValue)
+
+	case *ExpressionStatement:
+		a.applyExpression(n, "Expression", &n.Expression)
+
+	case *BlockStatement:
+		a.applyList(n, "Statements", &n.Statements)
+
+	case *WhileStatement:
+		a.applyExpression(n, "Condition", &n.Condition)
+		if n.Body != nil {
+			a.apply(n, "Body", func(r Node) { n.Body = r.(*BlockStatement) }, n.Body)
+		}
+
+	case *ForStatement:
+		if n.Variable != nil {
+			a.apply(n, "Variable", func(r Node) { n.Variable = r.(*Identifier) }, n.Variable)
+		}
+		a.applyExpression(n, "Iterable", &n.Iterable)
+		if n.Body != nil {
+			a.apply(n, "Body", func(r Node) { n.Body = r.(*BlockStatement) }, n.Body)
+		}
+
+	case *TryStatement:
+		if n.Block != nil {
+			a.apply(n, "Block", func(r Node) { n.Block = r.(*BlockStatement) }, n.Block)
+		}
+		if n.CatchParameter != nil {
+			a.apply(n, "CatchParameter", func(r Node) { n.CatchParameter = r.(*Identifier) }, n.CatchParameter)
+		}
+		if n.Catch != nil {
+			a.apply(n, "Catch", func(r Node) { n.Catch = r.(*BlockStatement) }, n.Catch)
+		}
+		if n.Finally != nil {
+			a.apply(n, "Finally", func(r Node) { n.Finally = r.(*BlockStatement) }, n.Finally)
+		}
+
+	case *ThrowStatement:
+		a.applyExpression(n, "Value", &n.Value)
+
+	case *PrefixExpression:
+		a.applyExpression(n, "Right", &n.Right)
+
+	case *InfixExpression:
+		a.applyExpression(n, "Left", &n.Left)
+		a.applyExpression(n, "Right", &n.Right)
+
+	case *AssignExpression:
+		a.applyExpression(n, "Target", &n.Target)
+		a.applyExpression(n, "Value", &n.Value)
+
+	case *IndexExpression:
+		a.applyExpression(n, "Left", &n.Left)
+		a.applyExpression(n, "Index", &n.Index)
+
+	case *IfExpression:
+		a.applyExpression(n, "Condition", &n.Condition)
+		if n.Consequence != nil {
+			a.apply(n, "Consequence", func(r Node) { n.Consequence = r.(*BlockStatement) }, n.Consequence)
+		}
+		if n.Alternative != nil {
+			a.apply(n, "Alternative", func(r Node) { n.Alternative = r.(*BlockStatement) }, n.Alternative)
+		}
+
+	case *CallExpression:
+		a.applyExpression(n, "Function", &n.Function)
+		for i := range n.Arguments {
+			a.applyExpression(n, "Arguments", &n.Arguments[i])
+		}
+
+	case *FunctionLiteral:
+		for i, param := range n.Parameters {
+			if param != nil {
+				a.apply(n, "Parameters", func(r Node) { n.Parameters[i] = r.(*Identifier) }, param)
+			}
+		}
+		if n.ReturnType != nil {
+			a.apply(n, "ReturnType", func(r Node) { n.ReturnType = r.(*TypeName) }, n.ReturnType)
+		}
+		if n.Body != nil {
+			a.apply(n, "Body", func(r Node) { n.Body = r.(*BlockStatement) }, n.Body)
+		}
+
+	case *MacroLiteral:
+		for i, param := range n.Parameters {
+			if param != nil {
+				a.apply(n, "Parameters", func(r Node) { n.Parameters[i] = r.(*Identifier) }, param)
+			}
+		}
+		if n.Body != nil {
+			a.apply(n, "Body", func(r Node) { n.Body = r.(*BlockStatement) }, n.Body)
+		}
+
+	case *TypeName, *IntegerLiteral, *StringLiteral, *Boolean, *BreakStatement, *ContinueStatement:
+		// nothing to do
+	}
+
+	if a.post != nil && !a.post(&a.cursor) {
+		panic(abort)
+	}
+}
+
+func (a *application) applyExpression(parent Node, name string, field *Expression) {
+	if *field != nil {
+		a.apply(parent, name, func(r Node) { *field = r.(Expression) }, *field)
+	}
+}
@@ -0,0 +1,13 @@
+package ast
+
+import "interpreter/token"
+
+type StringLiteral struct {
+	Token token.Token // the STRING token
+	Value string
+}
+
+func (s *StringLiteral) expressionNode()      {}
+func (s *StringLiteral) TokenLiteral() string { return s.Token.Literal }
+func (s *StringLiteral) Pos() token.Position  { return s.Token.Pos }
+func (s *StringLiteral) String() string       { return s.Token.Literal }
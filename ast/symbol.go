@@ -0,0 +1,22 @@
+package ast
+
+// SymbolScope tells where the value an identifier refers to lives
+type SymbolScope string
+
+const (
+	GlobalScope    SymbolScope = "GLOBAL"
+	LocalScope     SymbolScope = "LOCAL"
+	ParameterScope SymbolScope = "PARAMETER"
+	FreeScope      SymbolScope = "FREE"
+	BuiltinScope   SymbolScope = "BUILTIN"
+)
+
+// A Symbol is what an identifier resolves to. Index is the slot of the value
+// in its scope, parameters and locals of a function share the same slots,
+// parameters first.
+type Symbol struct {
+	Name  string
+	Scope SymbolScope
+	Index int
+	Decl  *Identifier // where the name is declared, nil for builtins
+}
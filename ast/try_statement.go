@@ -0,0 +1,55 @@
+package ast
+
+import (
+	"bytes"
+	"interpreter/token"
+)
+
+// TryStatement runs Block and, when it throws, Catch with the thrown value
+// bound to CatchParameter. Finally runs last either way. At least one of
+// Catch and Finally is present.
+type TryStatement struct {
+	Token          token.Token // the TRY token
+	Block          *BlockStatement
+	CatchParameter *Identifier
+	Catch          *BlockStatement
+	Finally        *BlockStatement
+}
+
+func (ts *TryStatement) statementNode()       {}
+func (ts *TryStatement) TokenLiteral() string { return ts.Token.Literal }
+func (ts *TryStatement) Pos() token.Position  { return ts.Token.Pos }
+func (ts *TryStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("try ")
+	out.WriteString(ts.Block.String())
+
+	if ts.Catch != nil {
+		out.WriteString(" catch(")
+		out.WriteString(ts.CatchParameter.String())
+		out.WriteString(") ")
+		out.WriteString(ts.Catch.String())
+	}
+
+	if ts.Finally != nil {
+		out.WriteString(" finally ")
+		out.WriteString(ts.Finally.String())
+	}
+
+	return out.String()
+}
+
+// ThrowStatement raises Value as an error, unwinding to the closest
+// enclosing try
+type ThrowStatement struct {
+	Token token.Token // the THROW token
+	Value Expression
+}
+
+func (ts *ThrowStatement) statementNode()       {}
+func (ts *ThrowStatement) TokenLiteral() string { return ts.Token.Literal }
+func (ts *ThrowStatement) Pos() token.Position  { return ts.Token.Pos }
+func (ts *ThrowStatement) String() string {
+	return "throw " + ts.Value.String() + ";"
+}
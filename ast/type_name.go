@@ -0,0 +1,13 @@
+package ast
+
+import "interpreter/token"
+
+// TypeName is a type annotation, like the int in let x: int = 5;
+type TypeName struct {
+	Token token.Token // the IDENT or FUNCTION token
+	Name  string
+}
+
+func (tn *TypeName) TokenLiteral() string { return tn.Token.Literal }
+func (tn *TypeName) Pos() token.Position  { return tn.Token.Pos }
+func (tn *TypeName) String() string       { return tn.Name }
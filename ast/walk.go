@@ -0,0 +1,161 @@
+package ast
+
+// A Visitor is called by Walk on every node. The visitor it returns is used
+// for the children of that node, and then called with nil once they are
+// done. Returning nil skips the children.
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order, children are visited in the
+// order they appear in the source. Missing children (nil) are skipped.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		walkStatements(v, n.Statements)
+
+	case *Identifier:
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+
+	case *LetStatement:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		walkExpression(v, n.Value)
+
+	case *ReturnStatement:
+		walkExpression(v, n.ReturnValue)
+
+	case *ExpressionStatement:
+		walkExpression(v, n.Expression)
+
+	case *BlockStatement:
+		walkStatements(v, n.Statements)
+
+	case *WhileStatement:
+		walkExpression(v, n.Condition)
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+
+	case *ForStatement:
+		if n.Variable != nil {
+			Walk(v, n.Variable)
+		}
+		walkExpression(v, n.Iterable)
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+
+	case *TryStatement:
+		if n.Block != nil {
+			Walk(v, n.Block)
+		}
+		if n.CatchParameter != nil {
+			Walk(v, n.CatchParameter)
+		}
+		if n.Catch != nil {
+			Walk(v, n.Catch)
+		}
+		if n.Finally != nil {
+			Walk(v, n.Finally)
+		}
+
+	case *ThrowStatement:
+		walkExpression(v, n.Value)
+
+	case *PrefixExpression:
+		walkExpression(v, n.Right)
+
+	case *InfixExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Right)
+
+	case *AssignExpression:
+		walkExpression(v, n.Target)
+		walkExpression(v, n.Value)
+
+	case *IndexExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Index)
+
+	case *IfExpression:
+		walkExpression(v, n.Condition)
+		if n.Consequence != nil {
+			Walk(v, n.Consequence)
+		}
+		if n.Alternative != nil {
+			Walk(v, n.Alternative)
+		}
+
+	case *CallExpression:
+		walkExpression(v, n.Function)
+		for _, arg := range n.Arguments {
+			walkExpression(v, arg)
+		}
+
+	case *FunctionLiteral:
+		for _, param := range n.Parameters {
+			if param != nil {
+				Walk(v, param)
+			}
+		}
+		if n.ReturnType != nil {
+			Walk(v, n.ReturnType)
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+
+	case *MacroLiteral:
+		for _, param := range n.Parameters {
+			if param != nil {
+				Walk(v, param)
+			}
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+
+	case *TypeName, *IntegerLiteral, *StringLiteral, *Boolean, *BreakStatement, *ContinueStatement:
+		// nothing to do
+	}
+
+	v.Visit(nil)
+}
+
+func walkStatements(v Visitor, list []Statement) {
+	for _, stmt := range list {
+		if stmt != nil {
+			Walk(v, stmt)
+		}
+	}
+}
+
+func walkExpression(v Visitor, exp Expression) {
+	if exp != nil {
+		Walk(v, exp)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect is Walk with a function: f is called on node and, while it
+// returns true, on its children, then with nil once the children of a node
+// are done. node must not be nil.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
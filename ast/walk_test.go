@@ -0,0 +1,156 @@
+package ast_test
+
+import (
+	"fmt"
+	"interpreter/ast"
+	"interpreter/lexer"
+	"interpreter/parser"
+	"interpreter/token"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %q", p.Errors())
+	}
+
+	return program
+}
+
+func TestInspect(t *testing.T) {
+	program := parse(t, `
+	  let x = -a + b[1];
+	  while (x) { x -= "s"; break; }
+	  for (i in items) { continue; }
+	  return;
+	`)
+
+	var visited []string
+	ast.Inspect(program, func(n ast.Node) bool {
+		if n != nil {
+			visited = append(visited, fmt.Sprintf("%T", n)[len("*ast."):])
+		}
+		return true
+	})
+
+	expected := []string{
+		"Program",
+		"LetStatement", "Identifier", "InfixExpression", "PrefixExpression", "Identifier",
+		"IndexExpression", "Identifier", "IntegerLiteral",
+		"WhileStatement", "Identifier", "BlockStatement", "ExpressionStatement", "AssignExpression",
+		"Identifier", "StringLiteral", "BreakStatement",
+		"ForStatement", "Identifier", "Identifier", "BlockStatement", "ContinueStatement",
+		"ReturnStatement",
+	}
+
+	if strings.Join(visited, " ") != strings.Join(expected, " ") {
+		t.Fatalf("wrong visit order.\nexpected=%v\ngot=     %v", expected, visited)
+	}
+}
+
+func TestInspectSkipsChildren(t *testing.T) {
+	program := parse(t, `let x = 1 + 2; while (y) { z; }`)
+
+	count := 0
+	ast.Inspect(program, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		count++
+
+		_, isStatement := n.(ast.Statement)
+		return !isStatement
+	})
+
+	// the program and its two statements
+	if count != 3 {
+		t.Fatalf("expected 3 nodes, got=%d", count)
+	}
+}
+
+func TestApplyReplace(t *testing.T) {
+	program := parse(t, `let x = 1 + 2; x = 3;`)
+
+	// turn every integer into its double
+	result := ast.Apply(program, nil, func(c *ast.Cursor) bool {
+		if il, ok := c.Node().(*ast.IntegerLiteral); ok {
+			value := il.Value * 2
+			c.Replace(&ast.IntegerLiteral{
+				Token: token.Token{Type: token.INT, Literal: fmt.Sprint(value)},
+				Value: value,
+			})
+		}
+		return true
+	})
+
+	if result.String() != "let x = (2 + 4);(x = 6)" {
+		t.Fatalf("wrong result, got=%q", result.String())
+	}
+}
+
+func TestApplyReplaceRoot(t *testing.T) {
+	exp := &ast.Identifier{Value: "x"}
+	replacement := &ast.Identifier{Value: "y"}
+
+	result := ast.Apply(exp, func(c *ast.Cursor) bool {
+		if c.Name() != "Root" || c.Parent() != nil {
+			t.Errorf("unexpected cursor for the root, name=%q parent=%v", c.Name(), c.Parent())
+		}
+		c.Replace(replacement)
+		return true
+	}, nil)
+
+	if result != replacement {
+		t.Fatalf("root was not replaced, got=%v", result)
+	}
+}
+
+func TestApplyStatementLists(t *testing.T) {
+	program := parse(t, `a; b; c; while (x) { d; e; }`)
+
+	result := ast.Apply(program, func(c *ast.Cursor) bool {
+		stmt, ok := c.Node().(*ast.ExpressionStatement)
+		if !ok {
+			return true
+		}
+
+		switch stmt.String() {
+		case "a":
+			c.Delete()
+		case "b":
+			c.InsertBefore(&ast.ExpressionStatement{Expression: &ast.Identifier{Value: "before"}})
+			c.InsertAfter(&ast.ExpressionStatement{Expression: &ast.Identifier{Value: "after"}})
+		case "e":
+			if c.Index() != 1 || c.Name() != "Statements" {
+				t.Errorf("wrong cursor for e, index=%d name=%q", c.Index(), c.Name())
+			}
+			c.Replace(&ast.BreakStatement{Token: token.Token{Type: token.BREAK, Literal: "break"}})
+		}
+		return true
+	}, nil)
+
+	if result.String() != "beforebaftercwhilex dbreak;" {
+		t.Fatalf("wrong result, got=%q", result.String())
+	}
+}
+
+func TestApplyAbort(t *testing.T) {
+	program := parse(t, `a; b; c;`)
+
+	var seen []string
+	ast.Apply(program, nil, func(c *ast.Cursor) bool {
+		if id, ok := c.Node().(*ast.Identifier); ok {
+			seen = append(seen, id.Value)
+			return id.Value != "b"
+		}
+		return true
+	})
+
+	if strings.Join(seen, "") != "ab" {
+		t.Fatalf("Apply did not stop after b, saw=%v", seen)
+	}
+}
@@ -0,0 +1,102 @@
+// Package astdump prints ASTs for debugging, either as an indented
+// S-expression or as a Graphviz DOT graph.
+package astdump
+
+import (
+	"fmt"
+	"interpreter/ast"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SExpr writes node as an S-expression, one node per line with its
+// children indented below it:
+//
+//	(InfixExpression +
+//	  (IntegerLiteral 1)
+//	  (Identifier x))
+func SExpr(w io.Writer, node ast.Node) error {
+	var out strings.Builder
+	depth := 0
+
+	ast.Apply(node, func(c *ast.Cursor) bool {
+		if depth > 0 {
+			out.WriteString("\n")
+		}
+		out.WriteString(strings.Repeat("  ", depth))
+		out.WriteString("(" + label(c.Node()))
+		depth++
+		return true
+	}, func(c *ast.Cursor) bool {
+		out.WriteString(")")
+		depth--
+		return true
+	})
+
+	out.WriteString("\n")
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// Dot writes node as a Graphviz DOT digraph, edges are labelled with the
+// field of the parent holding the child
+func Dot(w io.Writer, node ast.Node) error {
+	var out strings.Builder
+	var parents []int
+	next := 0
+
+	out.WriteString("digraph AST {\n")
+	out.WriteString("  node [shape=box, fontname=\"monospace\"];\n")
+
+	ast.Apply(node, func(c *ast.Cursor) bool {
+		id := next
+		next++
+
+		fmt.Fprintf(&out, "  n%d [label=%s];\n", id, strconv.Quote(label(c.Node())))
+
+		if len(parents) > 0 {
+			edge := c.Name()
+			if c.Index() >= 0 {
+				edge = fmt.Sprintf("%s[%d]", edge, c.Index())
+			}
+			fmt.Fprintf(&out, "  n%d -> n%d [label=%s];\n", parents[len(parents)-1], id, strconv.Quote(edge))
+		}
+
+		parents = append(parents, id)
+		return true
+	}, func(c *ast.Cursor) bool {
+		parents = parents[:len(parents)-1]
+		return true
+	})
+
+	out.WriteString("}\n")
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// label is the node kind followed by whatever the node holds besides its children
+func label(node ast.Node) string {
+	kind := fmt.Sprintf("%T", node)[len("*ast."):]
+
+	switch n := node.(type) {
+	case *ast.Identifier:
+		return kind + " " + n.Value
+	case *ast.TypeName:
+		return kind + " " + n.Name
+	case *ast.IntegerLiteral:
+		return kind + " " + strconv.FormatInt(n.Value, 10)
+	case *ast.StringLiteral:
+		return kind + " " + strconv.Quote(n.Value)
+	case *ast.Boolean:
+		return kind + " " + strconv.FormatBool(n.Value)
+	case *ast.PrefixExpression:
+		return kind + " " + n.Operator
+	case *ast.InfixExpression:
+		return kind + " " + n.Operator
+	case *ast.AssignExpression:
+		return kind + " " + n.Operator
+	default:
+		return kind
+	}
+}
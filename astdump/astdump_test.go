@@ -0,0 +1,67 @@
+package astdump
+
+import (
+	"bytes"
+	"interpreter/lexer"
+	"interpreter/parser"
+	"testing"
+)
+
+func TestSExpr(t *testing.T) {
+	p := parser.New(lexer.New(`let x = -1 + y * 2; h["k"] = x;`))
+	program := p.ParseProgram()
+
+	var out bytes.Buffer
+	if err := SExpr(&out, program); err != nil {
+		t.Fatalf("SExpr failed: %s", err)
+	}
+
+	expected := `(Program
+  (LetStatement
+    (Identifier x)
+    (InfixExpression +
+      (PrefixExpression -
+        (IntegerLiteral 1))
+      (InfixExpression *
+        (Identifier y)
+        (IntegerLiteral 2))))
+  (ExpressionStatement
+    (AssignExpression =
+      (IndexExpression
+        (Identifier h)
+        (StringLiteral "k"))
+      (Identifier x))))
+`
+
+	if out.String() != expected {
+		t.Errorf("wrong output.\nexpected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
+
+func TestDot(t *testing.T) {
+	p := parser.New(lexer.New(`a + "b";`))
+	program := p.ParseProgram()
+
+	var out bytes.Buffer
+	if err := Dot(&out, program); err != nil {
+		t.Fatalf("Dot failed: %s", err)
+	}
+
+	expected := `digraph AST {
+  node [shape=box, fontname="monospace"];
+  n0 [label="Program"];
+  n1 [label="ExpressionStatement"];
+  n0 -> n1 [label="Statements[0]"];
+  n2 [label="InfixExpression +"];
+  n1 -> n2 [label="Expression"];
+  n3 [label="Identifier a"];
+  n2 -> n3 [label="Left"];
+  n4 [label="StringLiteral \"b\""];
+  n2 -> n4 [label="Right"];
+}
+`
+
+	if out.String() != expected {
+		t.Errorf("wrong output.\nexpected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
@@ -0,0 +1,588 @@
+// Package astjson converts AST nodes to JSON and back, so parsed programs
+// can be handed to tools that are not written in Go.
+//
+// Every node is an object with its "kind" (the Go type name), its "token"
+// and "pos" when it has one, an "operator" or literal "value" where that
+// applies, and its "children" keyed by field name. A child is either a node
+// or a list of nodes:
+//
+//	{"kind": "InfixExpression", "token": {"type": "+", "literal": "+"},
+//	 "pos": {"offset": 2, "line": 1, "column": 3}, "operator": "+",
+//	 "children": {"left": {...}, "right": {...}}}
+package astjson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"interpreter/ast"
+	"interpreter/token"
+)
+
+type jsonToken struct {
+	Type    token.TokenType `json:"type"`
+	Literal string          `json:"literal"`
+}
+
+type jsonPos struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type jsonNode struct {
+	Kind     string          `json:"kind"`
+	Token    *jsonToken      `json:"token,omitempty"`
+	Pos      *jsonPos        `json:"pos,omitempty"`
+	Operator string          `json:"operator,omitempty"`
+	Value    json.RawMessage `json:"value,omitempty"`
+
+	Children map[string]json.RawMessage `json:"children,omitempty"`
+}
+
+// Marshal returns the JSON encoding of node
+func Marshal(node ast.Node) ([]byte, error) {
+	jn, err := encode(node)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(jn)
+}
+
+// Unmarshal decodes a node encoded by Marshal
+func Unmarshal(data []byte) (ast.Node, error) {
+	var jn *jsonNode
+	if err := json.Unmarshal(data, &jn); err != nil {
+		return nil, err
+	}
+
+	return decode(jn)
+}
+
+// Encoding
+
+func encode(node ast.Node) (*jsonNode, error) {
+	jn := &jsonNode{Kind: kind(node), Children: map[string]json.RawMessage{}}
+	e := &encoder{node: jn}
+
+	switch n := node.(type) {
+	case *ast.Program:
+		e.list("statements", nodes(n.Statements))
+
+	case *ast.LetStatement:
+		e.token(n.Token)
+		e.child("name", n.Name)
+		e.child("value", n.Value)
+
+	case *ast.ReturnStatement:
+		e.token(n.Token)
+		e.child("returnValue", n.ReturnValue)
+
+	case *ast.ExpressionStatement:
+		e.token(n.Token)
+		e.child("expression", n.Expression)
+
+	case *ast.BlockStatement:
+		e.token(n.Token)
+		e.list("statements", nodes(n.Statements))
+
+	case *ast.WhileStatement:
+		e.token(n.Token)
+		e.child("condition", n.Condition)
+		e.child("body", n.Body)
+
+	case *ast.ForStatement:
+		e.token(n.Token)
+		e.child("variable", n.Variable)
+		e.child("iterable", n.Iterable)
+		e.child("body", n.Body)
+
+	case *ast.TryStatement:
+		e.token(n.Token)
+		e.child("block", n.Block)
+		e.child("catchParameter", n.CatchParameter)
+		e.child("catch", n.Catch)
+		e.child("finally", n.Finally)
+
+	case *ast.ThrowStatement:
+		e.token(n.Token)
+		e.child("value", n.Value)
+
+	case *ast.BreakStatement:
+		e.token(n.Token)
+
+	case *ast.ContinueStatement:
+		e.token(n.Token)
+
+	case *ast.Identifier:
+		e.token(n.Token)
+		e.value(n.Value)
+		e.child("type", n.Type)
+
+	case *ast.TypeName:
+		e.token(n.Token)
+		e.value(n.Name)
+
+	case *ast.IntegerLiteral:
+		e.token(n.Token)
+		e.value(n.Value)
+
+	case *ast.StringLiteral:
+		e.token(n.Token)
+		e.value(n.Value)
+
+	case *ast.Boolean:
+		e.token(n.Token)
+		e.value(n.Value)
+
+	case *ast.IfExpression:
+		e.token(n.Token)
+		e.child("condition", n.Condition)
+		e.child("consequence", n.Consequence)
+		e.child("alternative", n.Alternative)
+
+	case *ast.PrefixExpression:
+		e.token(n.Token)
+		jn.Operator = n.Operator
+		e.child("right", n.Right)
+
+	case *ast.InfixExpression:
+		e.token(n.Token)
+		jn.Operator = n.Operator
+		e.child("left", n.Left)
+		e.child("right", n.Right)
+
+	case *ast.AssignExpression:
+		e.token(n.Token)
+		jn.Operator = n.Operator
+		e.child("target", n.Target)
+		e.child("value", n.Value)
+
+	case *ast.IndexExpression:
+		e.token(n.Token)
+		e.child("left", n.Left)
+		e.child("index", n.Index)
+
+	case *ast.CallExpression:
+		e.token(n.Token)
+		e.child("function", n.Function)
+		e.list("arguments", nodes(n.Arguments))
+
+	case *ast.FunctionLiteral:
+		e.token(n.Token)
+		e.list("parameters", nodes(n.Parameters))
+		e.child("returnType", n.ReturnType)
+		e.child("body", n.Body)
+
+	case *ast.MacroLiteral:
+		e.token(n.Token)
+		e.list("parameters", nodes(n.Parameters))
+		e.child("body", n.Body)
+
+	default:
+		return nil, fmt.Errorf("astjson: unsupported node %T", node)
+	}
+
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	return jn, nil
+}
+
+type encoder struct {
+	node *jsonNode
+	err  error
+}
+
+func (e *encoder) token(tok token.Token) {
+	e.node.Token = &jsonToken{Type: tok.Type, Literal: tok.Literal}
+	e.node.Pos = &jsonPos{Offset: tok.Pos.Offset, Line: tok.Pos.Line, Column: tok.Pos.Column}
+}
+
+func (e *encoder) value(v any) {
+	e.node.Value, e.err = json.Marshal(v)
+}
+
+func (e *encoder) child(name string, node ast.Node) {
+	if e.err != nil || isNil(node) {
+		return
+	}
+
+	var jn *jsonNode
+	if jn, e.err = encode(node); e.err == nil {
+		e.node.Children[name], e.err = json.Marshal(jn)
+	}
+}
+
+func (e *encoder) list(name string, children []ast.Node) {
+	list := []*jsonNode{}
+
+	for _, child := range children {
+		if e.err != nil {
+			return
+		}
+
+		var jn *jsonNode
+		jn, e.err = encode(child)
+		list = append(list, jn)
+	}
+
+	if e.err == nil {
+		e.node.Children[name], e.err = json.Marshal(list)
+	}
+}
+
+func nodes[T ast.Node](list []T) []ast.Node {
+	out := make([]ast.Node, len(list))
+	for i, n := range list {
+		out[i] = n
+	}
+	return out
+}
+
+// isNil reports whether node is missing, including typed nil pointers
+// stored in an interface like a nil *ast.BlockStatement
+func isNil(node ast.Node) bool {
+	switch n := node.(type) {
+	case nil:
+		return true
+	case *ast.Identifier:
+		return n == nil
+	case *ast.BlockStatement:
+		return n == nil
+	case *ast.TypeName:
+		return n == nil
+	}
+	return false
+}
+
+func kind(node ast.Node) string {
+	name := fmt.Sprintf("%T", node)
+	return name[len("*ast."):]
+}
+
+// Decoding
+
+func decode(jn *jsonNode) (ast.Node, error) {
+	if jn == nil {
+		return nil, errors.New("astjson: null node")
+	}
+
+	d := &decoder{node: jn}
+
+	var node ast.Node
+
+	switch jn.Kind {
+	case "Program":
+		node = &ast.Program{Statements: d.statements("statements")}
+
+	case "LetStatement":
+		node = &ast.LetStatement{Token: d.token(), Name: d.identifier("name"), Value: d.expression("value")}
+
+	case "ReturnStatement":
+		node = &ast.ReturnStatement{Token: d.token(), ReturnValue: d.expression("returnValue")}
+
+	case "ExpressionStatement":
+		node = &ast.ExpressionStatement{Token: d.token(), Expression: d.expression("expression")}
+
+	case "BlockStatement":
+		node = &ast.BlockStatement{Token: d.token(), Statements: d.statements("statements")}
+
+	case "WhileStatement":
+		node = &ast.WhileStatement{Token: d.token(), Condition: d.expression("condition"), Body: d.block("body")}
+
+	case "ForStatement":
+		node = &ast.ForStatement{
+			Token:    d.token(),
+			Variable: d.identifier("variable"),
+			Iterable: d.expression("iterable"),
+			Body:     d.block("body"),
+		}
+
+	case "TryStatement":
+		node = &ast.TryStatement{
+			Token:          d.token(),
+			Block:          d.block("block"),
+			CatchParameter: d.identifier("catchParameter"),
+			Catch:          d.block("catch"),
+			Finally:        d.block("finally"),
+		}
+
+	case "ThrowStatement":
+		node = &ast.ThrowStatement{Token: d.token(), Value: d.expression("value")}
+
+	case "BreakStatement":
+		node = &ast.BreakStatement{Token: d.token()}
+
+	case "ContinueStatement":
+		node = &ast.ContinueStatement{Token: d.token()}
+
+	case "Identifier":
+		n := &ast.Identifier{Token: d.token(), Type: d.typeName("type")}
+		d.value(&n.Value)
+		node = n
+
+	case "TypeName":
+		n := &ast.TypeName{Token: d.token()}
+		d.value(&n.Name)
+		node = n
+
+	case "IntegerLiteral":
+		n := &ast.IntegerLiteral{Token: d.token()}
+		d.value(&n.Value)
+		node = n
+
+	case "StringLiteral":
+		n := &ast.StringLiteral{Token: d.token()}
+		d.value(&n.Value)
+		node = n
+
+	case "Boolean":
+		n := &ast.Boolean{Token: d.token()}
+		d.value(&n.Value)
+		node = n
+
+	case "IfExpression":
+		node = &ast.IfExpression{
+			Token:       d.token(),
+			Condition:   d.expression("condition"),
+			Consequence: d.block("consequence"),
+			Alternative: d.block("alternative"),
+		}
+
+	case "PrefixExpression":
+		node = &ast.PrefixExpression{Token: d.token(), Operator: jn.Operator, Right: d.expression("right")}
+
+	case "InfixExpression":
+		node = &ast.InfixExpression{
+			Token:    d.token(),
+			Operator: jn.Operator,
+			Left:     d.expression("left"),
+			Right:    d.expression("right"),
+		}
+
+	case "AssignExpression":
+		node = &ast.AssignExpression{
+			Token:    d.token(),
+			Operator: jn.Operator,
+			Target:   d.expression("target"),
+			Value:    d.expression("value"),
+		}
+
+	case "IndexExpression":
+		node = &ast.IndexExpression{Token: d.token(), Left: d.expression("left"), Index: d.expression("index")}
+
+	case "CallExpression":
+		node = &ast.CallExpression{Token: d.token(), Function: d.expression("function"), Arguments: d.expressions("arguments")}
+
+	case "FunctionLiteral":
+		node = &ast.FunctionLiteral{
+			Token:      d.token(),
+			Parameters: d.identifiers("parameters"),
+			ReturnType: d.typeName("returnType"),
+			Body:       d.block("body"),
+		}
+
+	case "MacroLiteral":
+		node = &ast.MacroLiteral{Token: d.token(), Parameters: d.identifiers("parameters"), Body: d.block("body")}
+
+	default:
+		return nil, fmt.Errorf("astjson: unknown node kind %q", jn.Kind)
+	}
+
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	return node, nil
+}
+
+type decoder struct {
+	node *jsonNode
+	err  error
+}
+
+func (d *decoder) fail(format string, args ...any) {
+	if d.err == nil {
+		d.err = fmt.Errorf("astjson: %s: %s", d.node.Kind, fmt.Sprintf(format, args...))
+	}
+}
+
+func (d *decoder) token() token.Token {
+	var tok token.Token
+
+	if d.node.Token != nil {
+		tok.Type = d.node.Token.Type
+		tok.Literal = d.node.Token.Literal
+	}
+
+	if d.node.Pos != nil {
+		tok.Pos = token.Position{Offset: d.node.Pos.Offset, Line: d.node.Pos.Line, Column: d.node.Pos.Column}
+	}
+
+	return tok
+}
+
+func (d *decoder) value(v any) {
+	if d.err != nil {
+		return
+	}
+
+	if err := json.Unmarshal(d.node.Value, v); err != nil {
+		d.fail("invalid value: %s", err)
+	}
+}
+
+func (d *decoder) child(name string) ast.Node {
+	raw, ok := d.node.Children[name]
+	if !ok || d.err != nil {
+		return nil
+	}
+
+	// a null child is a missing one, like a bare return's value
+	var jn *jsonNode
+	if err := json.Unmarshal(raw, &jn); err != nil {
+		d.fail("invalid child %s: %s", name, err)
+		return nil
+	}
+	if jn == nil {
+		return nil
+	}
+
+	node, err := decode(jn)
+	if err != nil {
+		d.err = err
+		return nil
+	}
+
+	return node
+}
+
+func (d *decoder) expression(name string) ast.Expression {
+	node := d.child(name)
+	if node == nil {
+		return nil
+	}
+
+	exp, ok := node.(ast.Expression)
+	if !ok {
+		d.fail("child %s is not an expression, got %s", name, kind(node))
+	}
+	return exp
+}
+
+func (d *decoder) identifier(name string) *ast.Identifier {
+	node := d.child(name)
+	if node == nil {
+		return nil
+	}
+
+	ident, ok := node.(*ast.Identifier)
+	if !ok {
+		d.fail("child %s is not an Identifier, got %s", name, kind(node))
+	}
+	return ident
+}
+
+func (d *decoder) block(name string) *ast.BlockStatement {
+	node := d.child(name)
+	if node == nil {
+		return nil
+	}
+
+	block, ok := node.(*ast.BlockStatement)
+	if !ok {
+		d.fail("child %s is not a BlockStatement, got %s", name, kind(node))
+	}
+	return block
+}
+
+func (d *decoder) typeName(name string) *ast.TypeName {
+	node := d.child(name)
+	if node == nil {
+		return nil
+	}
+
+	tn, ok := node.(*ast.TypeName)
+	if !ok {
+		d.fail("child %s is not a TypeName, got %s", name, kind(node))
+	}
+	return tn
+}
+
+func (d *decoder) list(name string) []ast.Node {
+	raw, ok := d.node.Children[name]
+	if !ok || d.err != nil {
+		return nil
+	}
+
+	var list []*jsonNode
+	if err := json.Unmarshal(raw, &list); err != nil {
+		d.fail("invalid list %s: %s", name, err)
+		return nil
+	}
+
+	var nodes []ast.Node
+	for i, jn := range list {
+		if jn == nil {
+			d.fail("%s holds a null node at %d", name, i)
+			return nil
+		}
+
+		node, err := decode(jn)
+		if err != nil {
+			d.err = err
+			return nil
+		}
+		nodes = append(nodes, node)
+	}
+
+	return nodes
+}
+
+func (d *decoder) statements(name string) []ast.Statement {
+	var stmts []ast.Statement
+
+	for _, node := range d.list(name) {
+		stmt, ok := node.(ast.Statement)
+		if !ok {
+			d.fail("%s holds %s which is not a statement", name, kind(node))
+			return nil
+		}
+		stmts = append(stmts, stmt)
+	}
+
+	return stmts
+}
+
+func (d *decoder) expressions(name string) []ast.Expression {
+	var exps []ast.Expression
+
+	for _, node := range d.list(name) {
+		exp, ok := node.(ast.Expression)
+		if !ok {
+			d.fail("%s holds %s which is not an expression", name, kind(node))
+			return nil
+		}
+		exps = append(exps, exp)
+	}
+
+	return exps
+}
+
+func (d *decoder) identifiers(name string) []*ast.Identifier {
+	var idents []*ast.Identifier
+
+	for _, node := range d.list(name) {
+		ident, ok := node.(*ast.Identifier)
+		if !ok {
+			d.fail("%s holds %s which is not an Identifier", name, kind(node))
+			return nil
+		}
+		idents = append(idents, ident)
+	}
+
+	return idents
+}
@@ -0,0 +1,132 @@
+package astjson
+
+import (
+	"interpreter/ast"
+	"interpreter/lexer"
+	"interpreter/parser"
+	"interpreter/token"
+	"testing"
+)
+
+// inputs from parser_test.go, every one must survive a round trip
+var roundTripInputs = []string{
+	"let x = 5;\nlet y = 10;\nlet foobar = 838383;",
+	"return 5;\nreturn 10;\nreturn;",
+	"foobar;",
+	"5;",
+	"!5; -15;",
+	"5 + 5; 5 - 5; 5 * 5; 5 / 5; 5 > 5; 5 < 5; 5 == 5; 5 != 5;",
+	"-a * b; a + b * c - d; (a + b) * c; -(5 + 5); a < b == b > a;",
+	"x = 5; x += 5; x -= y * 2; x *= 5; x /= 5; x = y = 5;",
+	`arr[0] = 1; h["k"] = v; m[i][j] += 1;`,
+	"while (x < 10) { x += 1; continue; }",
+	"for (item in items) { found = item; break; }",
+	"try { f(); } catch (e) { throw e; } finally { close(); } try { g(); } finally { }",
+	"add(1, 2 * 3, f(x)); f(); (g)(h)[0];",
+	"let m = macro(a, b) { quote(unquote(a) + unquote(b)); }; let n = macro() { };",
+	"true; false; !true; if (x < y) { x } else { y }; if (true) { 1 };",
+	"fn() {}; let add = fn(x, y) { return x + y; }; fn(x) { x }(5);",
+	"let x: int = 5; let f = fn(a: int, b): bool { a == b };",
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, input := range roundTripInputs {
+		p := parser.New(lexer.New(input))
+		program := p.ParseProgram()
+
+		if len(p.Errors()) > 0 {
+			t.Fatalf("input %q - parser errors: %q", input, p.Errors())
+		}
+
+		data, err := Marshal(program)
+		if err != nil {
+			t.Fatalf("input %q - Marshal failed: %s", input, err)
+		}
+
+		node, err := Unmarshal(data)
+		if err != nil {
+			t.Fatalf("input %q - Unmarshal failed: %s", input, err)
+		}
+
+		decoded, ok := node.(*ast.Program)
+		if !ok {
+			t.Fatalf("input %q - decoded node is not an *ast.Program, got: %T", input, node)
+		}
+
+		if decoded.String() != program.String() {
+			t.Errorf("input %q - wrong program.\nexpected=%q\ngot=     %q", input, program.String(), decoded.String())
+		}
+
+		again, err := Marshal(decoded)
+		if err != nil {
+			t.Fatalf("input %q - Marshal of decoded program failed: %s", input, err)
+		}
+
+		if string(again) != string(data) {
+			t.Errorf("input %q - encoding changed after a round trip.\nfirst= %s\nsecond=%s", input, data, again)
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	exp := &ast.PrefixExpression{
+		Token:    token.Token{Type: token.MINUS, Literal: "-", Pos: token.Position{Offset: 0, Line: 1, Column: 1}},
+		Operator: "-",
+		Right: &ast.IntegerLiteral{
+			Token: token.Token{Type: token.INT, Literal: "5", Pos: token.Position{Offset: 1, Line: 1, Column: 2}},
+			Value: 5,
+		},
+	}
+
+	data, err := Marshal(exp)
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+
+	expected := `{"kind":"PrefixExpression","token":{"type":"-","literal":"-"},` +
+		`"pos":{"offset":0,"line":1,"column":1},"operator":"-","children":{"right":` +
+		`{"kind":"IntegerLiteral","token":{"type":"INT","literal":"5"},` +
+		`"pos":{"offset":1,"line":1,"column":2},"value":5}}}`
+
+	if string(data) != expected {
+		t.Errorf("wrong encoding.\nexpected=%s\ngot=     %s", expected, data)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"kind":"Nope"}`, `astjson: unknown node kind "Nope"`},
+		{
+			`{"kind":"LetStatement","children":{"name":{"kind":"IntegerLiteral","value":1}}}`,
+			"astjson: LetStatement: child name is not an Identifier, got IntegerLiteral",
+		},
+		{
+			`{"kind":"Program","children":{"statements":[{"kind":"Identifier","value":"x"}]}}`,
+			"astjson: Program: statements holds Identifier which is not a statement",
+		},
+		{
+			`{"kind":"Program","children":{"statements":[null]}}`,
+			"astjson: Program: statements holds a null node at 0",
+		},
+		{
+			`{"kind":"CallExpression","children":{"function":{"kind":"Identifier","value":"f"},"arguments":[{"kind":"Identifier","value":"x"},null]}}`,
+			"astjson: CallExpression: arguments holds a null node at 1",
+		},
+		{`null`, "astjson: null node"},
+		{`{"kind":"IntegerLiteral","value":"five"}`, "astjson: IntegerLiteral: invalid value: json: cannot unmarshal string into Go value of type int64"},
+	}
+
+	for _, tt := range tests {
+		_, err := Unmarshal([]byte(tt.input))
+		if err == nil {
+			t.Fatalf("input %s - expected an error", tt.input)
+		}
+
+		if err.Error() != tt.expected {
+			t.Errorf("input %s - wrong error.\nexpected=%q\ngot=     %q", tt.input, tt.expected, err)
+		}
+	}
+}
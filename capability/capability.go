@@ -0,0 +1,142 @@
+// Package capability controls what a Monkey program may do outside the
+// interpreter.
+//
+// An embedding host grants a Set of capabilities to a program, and each
+// builtin reaching outside the interpreter checks for the one it needs
+// before doing anything:
+//
+//	if err := caps.Check(capability.FileRead, "read_file"); err != nil {
+//		return err
+//	}
+//
+// Embedders start from Deny, granting only what their programs need, while
+// the monkey command runs with Permissive as any other interpreter would.
+package capability
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A Capability names something a program can be allowed to do
+type Capability string
+
+const (
+	FileRead  Capability = "fs.read"  // read files and list directories
+	FileWrite Capability = "fs.write" // create, write and remove files
+	Env       Capability = "env"      // read environment variables
+	Clock     Capability = "clock"    // read the current time
+	Random    Capability = "random"   // draw random numbers
+)
+
+// All returns every capability
+func All() []Capability {
+	return []Capability{FileRead, FileWrite, Env, Clock, Random}
+}
+
+// A Set is the capabilities granted to a program. Sets are values, Grant
+// and Revoke return a new Set, so one can be shared between programs.
+type Set struct {
+	granted map[Capability]bool
+}
+
+// Deny returns the empty Set, the default for programs run by an
+// embedding host
+func Deny() Set {
+	return Set{}
+}
+
+// Permissive returns the Set of every capability, used by the monkey
+// command
+func Permissive() Set {
+	return Deny().Grant(All()...)
+}
+
+// Grant returns s with caps added
+func (s Set) Grant(caps ...Capability) Set {
+	return s.with(caps, true)
+}
+
+// Revoke returns s without caps
+func (s Set) Revoke(caps ...Capability) Set {
+	return s.with(caps, false)
+}
+
+func (s Set) with(caps []Capability, granted bool) Set {
+	result := Set{granted: map[Capability]bool{}}
+	for c := range s.granted {
+		result.granted[c] = true
+	}
+
+	for _, c := range caps {
+		if granted {
+			result.granted[c] = true
+		} else {
+			delete(result.granted, c)
+		}
+	}
+
+	return result
+}
+
+// Has reports whether c is granted
+func (s Set) Has(c Capability) bool {
+	return s.granted[c]
+}
+
+// Check returns an *Error when builtin is called without c
+func (s Set) Check(c Capability, builtin string) error {
+	if s.Has(c) {
+		return nil
+	}
+	return &Error{Builtin: builtin, Capability: c}
+}
+
+// String returns the granted capabilities as a sorted comma separated list
+func (s Set) String() string {
+	var names []string
+	for c := range s.granted {
+		names = append(names, string(c))
+	}
+	sort.Strings(names)
+
+	return strings.Join(names, ",")
+}
+
+// Parse returns the Set of the comma separated capability names in list,
+// "all" standing for every capability
+func Parse(list string) (Set, error) {
+	known := map[Capability]bool{}
+	for _, c := range All() {
+		known[c] = true
+	}
+
+	s := Deny()
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+
+		switch {
+		case name == "":
+			continue
+		case name == "all":
+			s = s.Grant(All()...)
+		case known[Capability(name)]:
+			s = s.Grant(Capability(name))
+		default:
+			return Set{}, fmt.Errorf("unknown capability %q", name)
+		}
+	}
+
+	return s, nil
+}
+
+// An Error is returned by a builtin called without the capability it needs
+type Error struct {
+	Builtin    string
+	Capability Capability
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: not allowed without the %s capability", e.Builtin, e.Capability)
+}
@@ -0,0 +1,93 @@
+package capability
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	deny, permissive := Deny(), Permissive()
+
+	for _, c := range All() {
+		if deny.Has(c) {
+			t.Fatalf("Deny() has %s", c)
+		}
+		if !permissive.Has(c) {
+			t.Fatalf("Permissive() lacks %s", c)
+		}
+	}
+}
+
+func TestGrantAndRevoke(t *testing.T) {
+	base := Deny().Grant(Clock, Random)
+	granted := base.Grant(Env)
+	revoked := granted.Revoke(Clock, FileWrite)
+
+	tests := []struct {
+		set      Set
+		expected string
+	}{
+		{base, "clock,random"},
+		{granted, "clock,env,random"},
+		{revoked, "env,random"},
+		{Deny(), ""},
+		{Permissive(), "clock,env,fs.read,fs.write,random"},
+	}
+
+	for i, tt := range tests {
+		if tt.set.String() != tt.expected {
+			t.Fatalf("tests[%d] - expected=%q, got=%q", i, tt.expected, tt.set.String())
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	caps := Deny().Grant(FileRead)
+
+	if err := caps.Check(FileRead, "read_file"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	err := caps.Check(FileWrite, "write_file")
+
+	var capErr *Error
+	if !errors.As(err, &capErr) || capErr.Capability != FileWrite {
+		t.Fatalf("expected a capability error for fs.write, got %v", err)
+	}
+
+	expected := "write_file: not allowed without the fs.write capability"
+	if err.Error() != expected {
+		t.Fatalf("expected=%q, got=%q", expected, err.Error())
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		err      string
+	}{
+		{"", "", ""},
+		{"env, clock", "clock,env", ""},
+		{"all", "clock,env,fs.read,fs.write,random", ""},
+		{"fs.read,net", "", `unknown capability "net"`},
+	}
+
+	for _, tt := range tests {
+		s, err := Parse(tt.input)
+
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Fatalf("Parse(%q) expected error %q, got %v", tt.input, tt.err, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("Parse(%q) unexpected error: %s", tt.input, err)
+		}
+		if s.String() != tt.expected {
+			t.Fatalf("Parse(%q) expected=%q, got=%q", tt.input, tt.expected, s.String())
+		}
+	}
+}
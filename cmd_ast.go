@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"interpreter/ast"
+	"interpreter/astdump"
+	"interpreter/astjson"
+	"interpreter/lexer"
+	"interpreter/macro"
+	"interpreter/optimizer"
+	"interpreter/parser"
+	"io"
+	"os"
+)
+
+// runAst implements `monkey ast [-expand] [-optimize] [-json | -dot | -sexpr] [file]`,
+// printing the parsed program of file, or of stdin when no file is given
+func runAst(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("ast", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	asJSON := flags.Bool("json", false, "print the AST as JSON")
+	asDot := flags.Bool("dot", false, "print the AST as a Graphviz DOT graph")
+	asSExpr := flags.Bool("sexpr", false, "print the AST as an indented S-expression")
+	expand := flags.Bool("expand", false, "expand macros before printing")
+	optimize := flags.Bool("optimize", false, "fold constants and remove dead branches before printing")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	program, err := parseInput(flags.Arg(0), stdin)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if *expand {
+		expanded, err := macro.Expand(program, macro.Define(program))
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		program = expanded.(*ast.Program)
+	}
+
+	if *optimize {
+		if errs := optimizer.Optimize(program); len(errs) > 0 {
+			for _, err := range errs {
+				fmt.Fprintln(stderr, err)
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case *asDot:
+		astdump.Dot(stdout, program)
+		return 0
+	case *asSExpr:
+		astdump.SExpr(stdout, program)
+		return 0
+	case !*asJSON:
+		fmt.Fprintln(stdout, program.String())
+		return 0
+	}
+
+	data, err := astjson.Marshal(program)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	var out bytes.Buffer
+	json.Indent(&out, data, "", "  ")
+	out.WriteString("\n")
+	out.WriteTo(stdout)
+
+	return 0
+}
+
+// parseInput parses the named file, or stdin when name is empty
+func parseInput(name string, stdin io.Reader) (*ast.Program, error) {
+	in := stdin
+	if name != "" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	} else {
+		name = "<standard input>"
+	}
+
+	p := parser.New(lexer.NewReader(in))
+	program := p.ParseProgram()
+
+	if len(p.Errors()) > 0 {
+		var msg bytes.Buffer
+		fmt.Fprintf(&msg, "%s:", name)
+		for _, err := range p.Errors() {
+			fmt.Fprintf(&msg, "\n%s", err)
+		}
+		return nil, fmt.Errorf("%s", msg.String())
+	}
+
+	return program, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"interpreter/typecheck"
+	"io"
+)
+
+// runCheck implements `monkey check [files]`, type checking stdin when no
+// files are given
+func runCheck(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{""}
+	}
+
+	status := 0
+
+	for _, name := range files {
+		program, err := parseInput(name, stdin)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			status = 1
+			continue
+		}
+
+		if name == "" {
+			name = "<standard input>"
+		}
+
+		_, errs := typecheck.Check(program)
+		for _, err := range errs {
+			fmt.Fprintf(stdout, "%s:%s\n", name, err)
+			status = 1
+		}
+	}
+
+	return status
+}
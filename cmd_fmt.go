@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"interpreter/printer"
+	"io"
+	"os"
+)
+
+// runFmt implements `monkey fmt [-w] [-d] [files]`, formatting stdin when no
+// files are given
+func runFmt(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	write := flags.Bool("w", false, "write result to the source file instead of stdout")
+	diff := flags.Bool("d", false, "display diffs instead of rewriting files")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(stderr, "monkey fmt: cannot use -w with standard input")
+			return 2
+		}
+
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "monkey fmt: %s\n", err)
+			return 1
+		}
+
+		if err := formatFile("<standard input>", src, stdout, false, *diff); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	status := 0
+	for _, name := range flags.Args() {
+		src, err := os.ReadFile(name)
+		if err == nil {
+			err = formatFile(name, src, stdout, *write, *diff)
+		}
+
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			status = 1
+		}
+	}
+
+	return status
+}
+
+func formatFile(name string, src []byte, stdout io.Writer, write, diff bool) error {
+	out, err := printer.Source(src)
+	if err != nil {
+		return fmt.Errorf("%s:\n%s", name, err)
+	}
+
+	if diff {
+		fmt.Fprint(stdout, unifiedDiff(name, src, out))
+	}
+
+	if write {
+		if string(out) == string(src) {
+			return nil
+		}
+
+		info, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(name, out, info.Mode().Perm())
+	}
+
+	if !diff {
+		_, err = stdout.Write(out)
+	}
+
+	return err
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"interpreter/highlight"
+	"io"
+	"os"
+)
+
+// runHighlight implements `monkey highlight [-html] [file]`, coloring file,
+// or stdin when no file is given, with ANSI escapes or HTML spans
+func runHighlight(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("highlight", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	asHTML := flags.Bool("html", false, "print HTML spans instead of ANSI colors")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var src []byte
+	var err error
+	if name := flags.Arg(0); name != "" {
+		src, err = os.ReadFile(name)
+	} else {
+		src, err = io.ReadAll(stdin)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	render := highlight.ANSI
+	if *asHTML {
+		render = highlight.HTML
+	}
+
+	if err := render(stdout, string(src)); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	return 0
+}
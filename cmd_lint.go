@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"interpreter/lint"
+	"io"
+)
+
+type lintDiagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// runLint implements `monkey lint [-json] [-enable rules] [-disable rules] [files]`,
+// checking stdin when no files are given
+func runLint(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	asJSON := flags.Bool("json", false, "print diagnostics as JSON")
+	enable := flags.String("enable", "", "comma separated rules to run, all of them by default")
+	disable := flags.String("disable", "", "comma separated rules to skip")
+	list := flags.Bool("rules", false, "list the available rules")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *list {
+		for _, rule := range lint.Rules() {
+			fmt.Fprintf(stdout, "%-20s %s\n", rule.Name(), rule.Doc())
+		}
+		return 0
+	}
+
+	rules, err := lint.Select(*enable, *disable)
+	if err != nil {
+		fmt.Fprintf(stderr, "monkey lint: %s\n", err)
+		return 2
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{""}
+	}
+
+	status := 0
+	results := []lintDiagnostic{}
+
+	for _, name := range files {
+		program, err := parseInput(name, stdin)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			status = 1
+			continue
+		}
+
+		if name == "" {
+			name = "<standard input>"
+		}
+
+		for _, d := range lint.Run(program, rules) {
+			status = 1
+
+			if *asJSON {
+				results = append(results, lintDiagnostic{name, d.Pos.Line, d.Pos.Column, d.Rule, d.Message})
+			} else {
+				fmt.Fprintf(stdout, "%s:%s\n", name, d)
+			}
+		}
+	}
+
+	if *asJSON {
+		data, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Fprintln(stdout, string(data))
+	}
+
+	return status
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"interpreter/lsp"
+	"io"
+)
+
+// runLsp implements `monkey lsp`, serving the Language Server Protocol
+// over stdin and stdout until the editor exits
+func runLsp(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lsp", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if err := lsp.NewServer(stdin, stdout).Serve(); err != nil {
+		fmt.Fprintf(stderr, "monkey lsp: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
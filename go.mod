@@ -1,3 +1,3 @@
 module interpreter
 
-go 1.21.3
+go 1.23
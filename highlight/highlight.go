@@ -0,0 +1,170 @@
+// Package highlight colors Monkey source code for terminals and web pages.
+//
+// The source is split with the lexer and every token is given a Class from
+// its type. The text between tokens is copied as it is, so the rendered
+// output keeps the original layout.
+package highlight
+
+import (
+	"html"
+	"interpreter/lexer"
+	"interpreter/token"
+	"io"
+	"strings"
+)
+
+// Class is the highlighting category of a token
+type Class int
+
+const (
+	Plain Class = iota // identifiers and whitespace
+	Keyword
+	Number
+	String
+	Operator
+	Delimiter
+	Comment // reserved, the lexer does not produce comments yet
+	Illegal
+)
+
+var classNames = map[Class]string{
+	Plain:     "plain",
+	Keyword:   "keyword",
+	Number:    "number",
+	String:    "string",
+	Operator:  "operator",
+	Delimiter: "delimiter",
+	Comment:   "comment",
+	Illegal:   "illegal",
+}
+
+func (c Class) String() string { return classNames[c] }
+
+var classes = map[token.TokenType]Class{
+	token.INT:     Number,
+	token.STRING:  String,
+	token.ILLEGAL: Illegal,
+
+	token.ASSIGN:          Operator,
+	token.PLUS:            Operator,
+	token.MINUS:           Operator,
+	token.BANG:            Operator,
+	token.ASTERISK:        Operator,
+	token.SLASH:           Operator,
+	token.LT:              Operator,
+	token.GT:              Operator,
+	token.EQ:              Operator,
+	token.NOT_EQ:          Operator,
+	token.PLUS_ASSIGN:     Operator,
+	token.MINUS_ASSIGN:    Operator,
+	token.ASTERISK_ASSIGN: Operator,
+	token.SLASH_ASSIGN:    Operator,
+
+	token.COMMA:     Delimiter,
+	token.SEMICOLON: Delimiter,
+	token.COLON:     Delimiter,
+	token.LPAREN:    Delimiter,
+	token.RPAREN:    Delimiter,
+	token.LBRACE:    Delimiter,
+	token.RBRACE:    Delimiter,
+	token.LBRACKET:  Delimiter,
+	token.RBRACKET:  Delimiter,
+}
+
+func init() {
+	for _, t := range token.Keywords {
+		classes[t] = Keyword
+	}
+}
+
+// Classify returns the class of tokens of type t
+func Classify(t token.TokenType) Class {
+	return classes[t]
+}
+
+// A Span is a piece of the source, Start and End are byte offsets
+type Span struct {
+	Start, End int
+	Class      Class
+}
+
+// Spans splits src into classified spans covering all of it, whitespace
+// between tokens gets its own Plain spans
+func Spans(src string) []Span {
+	var spans []Span
+	var previous *token.Token
+
+	add := func(start, end int, class Class) {
+		if start < end {
+			spans = append(spans, Span{start, end, class})
+		}
+	}
+
+	// a token runs until the whitespace before the next one
+	flush := func(next int) {
+		if previous == nil {
+			add(0, next, Plain)
+			return
+		}
+
+		start := previous.Pos.Offset
+		end := start + len(strings.TrimRight(src[start:next], " \t\r\n"))
+		add(start, end, Classify(previous.Type))
+		add(end, next, Plain)
+	}
+
+	for tok := range lexer.New(src).Tokens() {
+		flush(tok.Pos.Offset)
+		previous = &tok
+	}
+	flush(len(src))
+
+	return spans
+}
+
+var ansiColors = map[Class]string{
+	Keyword:  "\x1b[1;35m",
+	Number:   "\x1b[36m",
+	String:   "\x1b[32m",
+	Operator: "\x1b[33m",
+	Comment:  "\x1b[90m",
+	Illegal:  "\x1b[4;31m",
+}
+
+const ansiReset = "\x1b[0m"
+
+// ANSI writes src to w colored with ANSI escape sequences
+func ANSI(w io.Writer, src string) error {
+	var out strings.Builder
+
+	for _, span := range Spans(src) {
+		text := src[span.Start:span.End]
+		if color, ok := ansiColors[span.Class]; ok {
+			out.WriteString(color + text + ansiReset)
+		} else {
+			out.WriteString(text)
+		}
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// HTML writes src to w with every token but identifiers wrapped in a span
+// of CSS class mk-<class>, like <span class="mk-keyword">let</span>. The
+// output is meant to go inside a <pre> element.
+func HTML(w io.Writer, src string) error {
+	var out strings.Builder
+
+	for _, span := range Spans(src) {
+		text := html.EscapeString(src[span.Start:span.End])
+		if span.Class == Plain {
+			out.WriteString(text)
+		} else {
+			out.WriteString(`<span class="mk-` + span.Class.String() + `">` + text + "</span>")
+		}
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
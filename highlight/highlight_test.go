@@ -0,0 +1,100 @@
+package highlight
+
+import (
+	"interpreter/token"
+	"strings"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		tokenType token.TokenType
+		expected  Class
+	}{
+		{token.LET, Keyword},
+		{token.FUNCTION, Keyword},
+		{token.TRUE, Keyword},
+		{token.WHILE, Keyword},
+		{token.IDENT, Plain},
+		{token.INT, Number},
+		{token.STRING, String},
+		{token.PLUS_ASSIGN, Operator},
+		{token.NOT_EQ, Operator},
+		{token.LBRACKET, Delimiter},
+		{token.COLON, Delimiter},
+		{token.ILLEGAL, Illegal},
+	}
+
+	for _, tt := range tests {
+		if got := Classify(tt.tokenType); got != tt.expected {
+			t.Fatalf("Classify(%s) expected=%s, got=%s", tt.tokenType, tt.expected, got)
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"let x = 5;",
+			`<span class="mk-keyword">let</span> x <span class="mk-operator">=</span> <span class="mk-number">5</span><span class="mk-delimiter">;</span>`,
+		},
+		{
+			"\n  if (a < b) {\n\treturn \"<é>\";\n  }\n",
+			"\n  <span class=\"mk-keyword\">if</span> <span class=\"mk-delimiter\">(</span>a <span class=\"mk-operator\">&lt;</span> b" +
+				"<span class=\"mk-delimiter\">)</span> <span class=\"mk-delimiter\">{</span>\n\t<span class=\"mk-keyword\">return</span> " +
+				"<span class=\"mk-string\">&#34;&lt;é&gt;&#34;</span><span class=\"mk-delimiter\">;</span>\n  <span class=\"mk-delimiter\">}</span>\n",
+		},
+		{"x @ 12ab", `x <span class="mk-illegal">@</span> <span class="mk-illegal">12ab</span>`},
+		{"\"open   ", `<span class="mk-illegal">&#34;open</span>   `},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		var out strings.Builder
+		if err := HTML(&out, tt.input); err != nil {
+			t.Fatalf("HTML failed: %s", err)
+		}
+
+		if out.String() != tt.expected {
+			t.Fatalf("wrong HTML for %q\nexpected=%q\ngot=%q", tt.input, tt.expected, out.String())
+		}
+	}
+}
+
+func TestANSI(t *testing.T) {
+	var out strings.Builder
+	ANSI(&out, "fn(a) {\n  a + 1 }")
+
+	expected := "\x1b[1;35mfn\x1b[0m(a) {\n  a \x1b[33m+\x1b[0m \x1b[36m1\x1b[0m }"
+	if out.String() != expected {
+		t.Fatalf("wrong ANSI output\nexpected=%q\ngot=%q", expected, out.String())
+	}
+}
+
+func TestSpansCoverSource(t *testing.T) {
+	inputs := []string{
+		"let add = fn(a: int, b) { a + b };\n\n  add(1, 2)  ",
+		"  \t\n",
+		"while (true) { break; }",
+	}
+
+	for _, input := range inputs {
+		var rebuilt strings.Builder
+		previous := 0
+
+		for _, span := range Spans(input) {
+			if span.Start != previous {
+				t.Fatalf("gap before span %v in %q", span, input)
+			}
+			rebuilt.WriteString(input[span.Start:span.End])
+			previous = span.End
+		}
+
+		if rebuilt.String() != input {
+			t.Fatalf("spans do not cover %q, got %q", input, rebuilt.String())
+		}
+	}
+}
@@ -1,39 +1,85 @@
 package lexer
 
 import (
+	"bufio"
+	"fmt"
 	"interpreter/token"
+	"io"
+	"strings"
 )
 
 type Lexer struct {
-	input        string
+	reader       io.RuneScanner
 	position     int  // current position in input (points to current char)
 	readPosition int  // current reading position in input (after current char)
-	ch           byte // current char under examination
+	ch           rune // current char under examination
+	line         int  // line of the current char
+	column       int  // column of the current char
+	errors       []Error
+}
+
+// Error is a lexical error found while reading the input,
+// the offending characters are still returned as an ILLEGAL token.
+type Error struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	return NewReader(strings.NewReader(input))
+}
+
+// NewReader builds a Lexer that pulls its input from r as tokens are requested,
+// so large inputs never need to be held in memory as a whole.
+// The token stream is the same one New would produce for the same input.
+func NewReader(r io.Reader) *Lexer {
+	rs, ok := r.(io.RuneScanner)
+	if !ok {
+		rs = bufio.NewReader(r)
+	}
+
+	l := &Lexer{reader: rs, line: 1}
 	l.readChar()
 	return l
 }
 
+// Errors returns the lexical errors found so far.
+func (l *Lexer) Errors() []Error {
+	return l.errors
+}
+
 func (l *Lexer) readChar() {
-	if l.readPosition >= len(l.input) {
+	if l.ch == '\n' {
+		l.line += 1
+		l.column = 1
+	} else {
+		l.column += 1
+	}
+
+	ch, size, err := l.reader.ReadRune()
+	if err != nil {
 		l.ch = 0
+		size = 1
 	} else {
-		l.ch = l.input[l.readPosition]
+		l.ch = ch
 	}
 
 	l.position = l.readPosition
-	l.readPosition += 1
+	l.readPosition += size
 }
 
-func (l *Lexer) peekChar() byte {
-	if (l.readPosition) >= len(l.input) {
+func (l *Lexer) peekChar() rune {
+	ch, _, err := l.reader.ReadRune()
+	if err != nil {
 		return 0
-	} else {
-		return l.input[l.readPosition]
 	}
+
+	l.reader.UnreadRune()
+	return ch
 }
 
 func (l *Lexer) NextToken() token.Token {
@@ -41,6 +87,8 @@ func (l *Lexer) NextToken() token.Token {
 
 	l.skipWhitespace()
 
+	pos := l.pos()
+
 	switch l.ch {
 	case '=':
 		if l.peekChar() == '=' {
@@ -52,11 +100,19 @@ func (l *Lexer) NextToken() token.Token {
 		}
 
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		if l.peekChar() == '=' {
+			tok.Literal = "+="
+			tok.Type = token.PLUS_ASSIGN
+			l.readChar()
+		} else {
+			tok = newToken(token.PLUS, l.ch)
+		}
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
+	case ':':
+		tok = newToken(token.COLON, l.ch)
 	case '(':
 		tok = newToken(token.LPAREN, l.ch)
 	case ')':
@@ -65,8 +121,18 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
 		tok = newToken(token.RBRACE, l.ch)
+	case '[':
+		tok = newToken(token.LBRACKET, l.ch)
+	case ']':
+		tok = newToken(token.RBRACKET, l.ch)
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		if l.peekChar() == '=' {
+			tok.Literal = "-="
+			tok.Type = token.MINUS_ASSIGN
+			l.readChar()
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
 	case '!':
 		if l.peekChar() == '=' {
 			tok.Literal = "!="
@@ -76,13 +142,34 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.BANG, l.ch)
 		}
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch)
+		if l.peekChar() == '=' {
+			tok.Literal = "*="
+			tok.Type = token.ASTERISK_ASSIGN
+			l.readChar()
+		} else {
+			tok = newToken(token.ASTERISK, l.ch)
+		}
 	case '/':
-		tok = newToken(token.SLASH, l.ch)
+		if l.peekChar() == '=' {
+			tok.Literal = "/="
+			tok.Type = token.SLASH_ASSIGN
+			l.readChar()
+		} else {
+			tok = newToken(token.SLASH, l.ch)
+		}
 	case '<':
 		tok = newToken(token.LT, l.ch)
 	case '>':
 		tok = newToken(token.GT, l.ch)
+	case '"':
+		literal, ok := l.readString()
+		if ok {
+			tok.Type = token.STRING
+		} else {
+			tok.Type = token.ILLEGAL
+			l.error(pos, "unterminated string")
+		}
+		tok.Literal = literal
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -90,55 +177,108 @@ func (l *Lexer) NextToken() token.Token {
 		if isLetter(l.ch) {
 			tok.Literal = l.readIndentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Pos = pos
 			return tok
 		} else if isDigit(l.ch) {
 			tok.Literal = l.readNumber()
 			tok.Type = token.INT
+
+			// a number running into letters like 12abc is not two separate tokens
+			if isLetter(l.ch) {
+				tok.Literal += l.readMalformedNumber()
+				tok.Type = token.ILLEGAL
+				l.error(pos, fmt.Sprintf("malformed number %q", tok.Literal))
+			}
+
+			tok.Pos = pos
 			return tok
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
+			l.error(pos, fmt.Sprintf("unexpected character %q", l.ch))
 		}
 	}
 
 	l.readChar()
 
+	tok.Pos = pos
 	return tok
 }
 
+func (l *Lexer) pos() token.Position {
+	return token.Position{Offset: l.position, Line: l.line, Column: l.column}
+}
+
+func (l *Lexer) error(pos token.Position, msg string) {
+	l.errors = append(l.errors, Error{Pos: pos, Msg: msg})
+}
+
 func (l *Lexer) skipWhitespace() {
 	for l.ch == ' ' || l.ch == '\t' || l.ch == '\r' || l.ch == '\n' {
 		l.readChar()
 	}
 }
 
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
 
 func (l *Lexer) readIndentifier() string {
-	position := l.position
+	var out strings.Builder
 
 	for isLetter(l.ch) {
+		out.WriteRune(l.ch)
 		l.readChar()
 	}
 
-	return l.input[position:l.position]
+	return out.String()
+}
+
+// readString reads the characters between the quotes, leaving l.ch on the
+// closing one. It reports false when the input ends before the string does.
+func (l *Lexer) readString() (string, bool) {
+	var out strings.Builder
+
+	for {
+		l.readChar()
+
+		if l.ch == '"' {
+			return out.String(), true
+		}
+
+		if l.ch == 0 {
+			return out.String(), false
+		}
+
+		out.WriteRune(l.ch)
+	}
 }
 
-func isLetter(ch byte) bool {
+func isLetter(ch rune) bool {
 	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
 }
 
-func isDigit(ch byte) bool {
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
+func (l *Lexer) readMalformedNumber() string {
+	var out strings.Builder
+
+	for isLetter(l.ch) || isDigit(l.ch) {
+		out.WriteRune(l.ch)
+		l.readChar()
+	}
+
+	return out.String()
+}
+
 func (l *Lexer) readNumber() string {
-	position := l.position
+	var out strings.Builder
 
 	for isDigit(l.ch) {
+		out.WriteRune(l.ch)
 		l.readChar()
 	}
 
-	return l.input[position:l.position]
+	return out.String()
 }
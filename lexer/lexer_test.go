@@ -2,7 +2,9 @@ package lexer
 
 import (
 	"interpreter/token"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestNextToken(t *testing.T) {
@@ -23,7 +25,10 @@ func TestNextToken(t *testing.T) {
 				return false;
 			  }
 			  10 == 10;
-			  10 != 9;`
+			  10 != 9;
+			  x += 1; x -= 1; x *= 2; x /= 2;
+			  arr[0] = "foo bar";
+			  let n: int = 1;`
 
 	tests := []struct {
 		expectedType    token.TokenType
@@ -102,6 +107,36 @@ func TestNextToken(t *testing.T) {
 		{token.NOT_EQ, "!="},
 		{token.INT, "9"},
 		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.PLUS_ASSIGN, "+="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.MINUS_ASSIGN, "-="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.ASTERISK_ASSIGN, "*="},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.SLASH_ASSIGN, "/="},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "arr"},
+		{token.LBRACKET, "["},
+		{token.INT, "0"},
+		{token.RBRACKET, "]"},
+		{token.ASSIGN, "="},
+		{token.STRING, "foo bar"},
+		{token.SEMICOLON, ";"},
+		{token.LET, "let"},
+		{token.IDENT, "n"},
+		{token.COLON, ":"},
+		{token.IDENT, "int"},
+		{token.ASSIGN, "="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
 		{token.EOF, ""},
 	}
 
@@ -120,3 +155,147 @@ func TestNextToken(t *testing.T) {
 	}
 
 }
+
+func TestNewReader(t *testing.T) {
+	input := `let add = fn(x, y) { x + y; };
+			  let result = add(five, ten);
+			  !-/*5;
+			  if (5 < 10) { return true; } else { return false; }
+			  10 == 10; 10 != 9; @ é`
+
+	expected := New(input)
+	l := NewReader(iotest.OneByteReader(strings.NewReader(input)))
+
+	for i := 0; ; i++ {
+		want := expected.NextToken()
+		got := l.NextToken()
+
+		if got != want {
+			t.Fatalf("tokens[%d] - wrong token. expected=%+v, got=%+v", i, want, got)
+		}
+
+		if want.Type == token.EOF {
+			break
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	input := `let x = 5 + y;`
+
+	expected := []token.TokenType{
+		token.LET, token.IDENT, token.ASSIGN, token.INT, token.PLUS, token.IDENT, token.SEMICOLON,
+	}
+
+	i := 0
+	for tok := range New(input).Tokens() {
+		if i >= len(expected) {
+			t.Fatalf("too many tokens, got extra %+v", tok)
+		}
+
+		if tok.Type != expected[i] {
+			t.Fatalf("tokens[%d] - tokentype wrong. expected=%q, got=%q", i, expected[i], tok.Type)
+		}
+		i++
+	}
+
+	if i != len(expected) {
+		t.Fatalf("wrong number of tokens. expected=%d, got=%d", len(expected), i)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tokens, errs := Tokenize(`let a = 1 @ 2;`)
+
+	if len(tokens) != 7 {
+		t.Fatalf("wrong number of tokens. expected=7, got=%d", len(tokens))
+	}
+
+	if tokens[4].Type != token.ILLEGAL || tokens[4].Literal != "@" {
+		t.Fatalf("tokens[4] is not ILLEGAL '@', got=%+v", tokens[4])
+	}
+
+	if len(errs) != 1 {
+		t.Fatalf("wrong number of errors. expected=1, got=%d", len(errs))
+	}
+
+	if errs[0].Error() != `1:11: unexpected character '@'` {
+		t.Fatalf("wrong error message, got=%q", errs[0].Error())
+	}
+}
+
+func TestPositions(t *testing.T) {
+	input := "let x = 5;\n  x == \"é\";"
+
+	tests := []struct {
+		expectedType   token.TokenType
+		expectedOffset int
+		expectedLine   int
+		expectedColumn int
+	}{
+		{token.LET, 0, 1, 1},
+		{token.IDENT, 4, 1, 5},
+		{token.ASSIGN, 6, 1, 7},
+		{token.INT, 8, 1, 9},
+		{token.SEMICOLON, 9, 1, 10},
+		{token.IDENT, 13, 2, 3},
+		{token.EQ, 15, 2, 5},
+		{token.STRING, 18, 2, 8},
+		{token.SEMICOLON, 22, 2, 11},
+		{token.EOF, 23, 2, 12},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+
+		expected := token.Position{Offset: tt.expectedOffset, Line: tt.expectedLine, Column: tt.expectedColumn}
+		if tok.Pos != expected {
+			t.Fatalf("tests[%d] - position wrong. expected=%+v, got=%+v", i, expected, tok.Pos)
+		}
+	}
+}
+
+func TestLexicalErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedLiteral string
+		expectedError   string
+	}{
+		{"@", "@", "1:1: unexpected character '@'"},
+		{"let x = 12ab3;", "12ab3", `1:9: malformed number "12ab3"`},
+		{"\n  \"hello", "hello", "2:3: unterminated string"},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+
+		var illegal []token.Token
+		for tok := range l.Tokens() {
+			if tok.Type == token.ILLEGAL {
+				illegal = append(illegal, tok)
+			}
+		}
+
+		if len(illegal) != 1 {
+			t.Fatalf("input %q - expected 1 ILLEGAL token, got=%d", tt.input, len(illegal))
+		}
+
+		if illegal[0].Literal != tt.expectedLiteral {
+			t.Errorf("input %q - literal wrong. expected=%q, got=%q", tt.input, tt.expectedLiteral, illegal[0].Literal)
+		}
+
+		if len(l.Errors()) != 1 {
+			t.Fatalf("input %q - expected 1 error, got=%d", tt.input, len(l.Errors()))
+		}
+
+		if l.Errors()[0].Error() != tt.expectedError {
+			t.Errorf("input %q - error wrong. expected=%q, got=%q", tt.input, tt.expectedError, l.Errors()[0].Error())
+		}
+	}
+}
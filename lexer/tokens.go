@@ -0,0 +1,36 @@
+package lexer
+
+import (
+	"interpreter/token"
+	"iter"
+)
+
+// Tokens returns an iterator over the remaining tokens of the input.
+// The iteration stops before the EOF token.
+func (l *Lexer) Tokens() iter.Seq[token.Token] {
+	return func(yield func(token.Token) bool) {
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+			if !yield(tok) {
+				return
+			}
+		}
+	}
+}
+
+// Tokenize lexes the whole input at once, returning every token up to (but not
+// including) EOF together with the lexical errors found.
+func Tokenize(input string) ([]token.Token, []error) {
+	var tokens []token.Token
+	var errors []error
+
+	l := New(input)
+	for tok := range l.Tokens() {
+		tokens = append(tokens, tok)
+	}
+
+	for _, err := range l.Errors() {
+		errors = append(errors, err)
+	}
+
+	return tokens, errors
+}
@@ -0,0 +1,109 @@
+// Package lint finds suspicious code in Monkey programs.
+//
+// Each check is a Rule looking at the AST of a whole program and reporting
+// the nodes it finds questionable. Run applies a set of rules and collects
+// their diagnostics in source order.
+package lint
+
+import (
+	"fmt"
+	"interpreter/ast"
+	"interpreter/token"
+	"sort"
+	"strings"
+)
+
+// A Diagnostic is a problem reported by a rule
+type Diagnostic struct {
+	Pos     token.Position
+	Rule    string
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s (%s)", d.Pos, d.Message, d.Rule)
+}
+
+// A Reporter records a diagnostic about node
+type Reporter func(node ast.Node, format string, args ...any)
+
+type Rule interface {
+	Name() string
+	Doc() string // one line description of what the rule reports
+	Check(program *ast.Program, report Reporter)
+}
+
+// Rules returns every available rule
+func Rules() []Rule {
+	return []Rule{
+		unusedRule{},
+		shadowRule{},
+		unreachableRule{},
+		constantConditionRule{},
+		selfComparisonRule{},
+	}
+}
+
+// Select returns the rules enabled by the comma separated lists of rule
+// names in enable (all of them when empty) minus the ones in disable
+func Select(enable, disable string) ([]Rule, error) {
+	byName := map[string]Rule{}
+	for _, rule := range Rules() {
+		byName[rule.Name()] = rule
+	}
+
+	names := func(list string) (map[string]bool, error) {
+		set := map[string]bool{}
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			if _, ok := byName[name]; !ok {
+				return nil, fmt.Errorf("unknown lint rule %q", name)
+			}
+			set[name] = true
+		}
+		return set, nil
+	}
+
+	enabled, err := names(enable)
+	if err != nil {
+		return nil, err
+	}
+
+	disabled, err := names(disable)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []Rule
+	for _, rule := range Rules() {
+		if (len(enabled) == 0 || enabled[rule.Name()]) && !disabled[rule.Name()] {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, nil
+}
+
+// Run checks program with every rule and returns the diagnostics sorted by position
+func Run(program *ast.Program, rules []Rule) []Diagnostic {
+	var diagnostics []Diagnostic
+
+	for _, rule := range rules {
+		name := rule.Name()
+		rule.Check(program, func(node ast.Node, format string, args ...any) {
+			diagnostics = append(diagnostics, Diagnostic{
+				Pos:     node.Pos(),
+				Rule:    name,
+				Message: fmt.Sprintf(format, args...),
+			})
+		})
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Pos.Offset < diagnostics[j].Pos.Offset
+	})
+
+	return diagnostics
+}
@@ -0,0 +1,140 @@
+package lint
+
+import (
+	"interpreter/lexer"
+	"interpreter/parser"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		rule     string
+		input    string
+		expected []string
+	}{
+		{
+			"unused",
+			`let a = 1; let b = a; let _ = 2; let m = macro(x) { let y = 1; quote(x) }; m;`,
+			[]string{"1:16: b declared and not used (unused)", "1:57: y declared and not used (unused)"},
+		},
+		{
+			"unused",
+			"let x = 1;\nlet x = x + 1;",
+			[]string{"2:5: x declared and not used (unused)"},
+		},
+		{
+			"unused",
+			"let y = y;",
+			[]string{"1:5: y declared and not used (unused)"},
+		},
+		{
+			"unused",
+			"let xs = 1; for (xs in xs) { xs }",
+			nil,
+		},
+		{
+			"shadow",
+			"let a = 1;\nlet m = macro(a, b) { let b = 2; quote(b) };\nlet a = 3;",
+			[]string{
+				"2:15: a shadows the declaration at 1:5 (shadow)",
+				"2:27: b shadows the declaration at 2:18 (shadow)",
+				"3:5: a shadows the declaration at 1:5 (shadow)",
+			},
+		},
+		{
+			"shadow",
+			"let xs = 1; for (xs in xs) { }",
+			[]string{"1:18: xs shadows the declaration at 1:5 (shadow)"},
+		},
+		{
+			"unreachable",
+			"while (x) { break; x; y; } return 1; let a = 2;",
+			[]string{"1:20: unreachable code after break (unreachable)", "1:38: unreachable code after return (unreachable)"},
+		},
+		{
+			"shadow",
+			"let e = 1; try { f(); } catch (e) { e }",
+			[]string{"1:32: e shadows the declaration at 1:5 (shadow)"},
+		},
+		{
+			"shadow",
+			"try { let e = 1; e } catch (e) { e }",
+			[]string{"1:29: e shadows the declaration at 1:11 (shadow)"},
+		},
+		{
+			"unreachable",
+			"while (x) { x; continue; }",
+			nil,
+		},
+		{
+			"unreachable",
+			"try { throw 1; f(); } catch (e) { e }",
+			[]string{"1:16: unreachable code after throw (unreachable)"},
+		},
+		{
+			"constant-condition",
+			`if (true) { a }; if (!(1 < 2)) { b }; if ("s") { c }; if (x) { d }; if (x == 1) { e }`,
+			[]string{
+				"1:1: condition true is constant (constant-condition)",
+				"1:18: condition !(1 < 2) is constant (constant-condition)",
+				"1:39: condition \"s\" is constant (constant-condition)",
+			},
+		},
+		{
+			"self-comparison",
+			`x == x; a[0] != a[0]; x > x; f() == f(); x == y; x + x; "a" == a;`,
+			[]string{
+				"1:3: x == x is always true (self-comparison)",
+				"1:14: a[0] != a[0] is always false (self-comparison)",
+				"1:25: x > x is always false (self-comparison)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+
+		if len(p.Errors()) > 0 {
+			t.Fatalf("input %q - parser errors: %q", tt.input, p.Errors())
+		}
+
+		rules, err := Select(tt.rule, "")
+		if err != nil {
+			t.Fatalf("Select failed: %s", err)
+		}
+
+		diagnostics := Run(program, rules)
+
+		if len(diagnostics) != len(tt.expected) {
+			t.Fatalf("rule %s, input %q - expected %d diagnostics, got %d: %v", tt.rule, tt.input, len(tt.expected), len(diagnostics), diagnostics)
+		}
+
+		for i, d := range diagnostics {
+			if d.String() != tt.expected[i] {
+				t.Errorf("rule %s, input %q - diagnostics[%d] wrong.\nexpected=%q\ngot=     %q", tt.rule, tt.input, i, tt.expected[i], d.String())
+			}
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	rules, err := Select("", "unused, shadow")
+	if err != nil {
+		t.Fatalf("Select failed: %s", err)
+	}
+
+	if len(rules) != len(Rules())-2 {
+		t.Fatalf("expected %d rules, got %d", len(Rules())-2, len(rules))
+	}
+
+	for _, rule := range rules {
+		if rule.Name() == "unused" || rule.Name() == "shadow" {
+			t.Errorf("rule %s should be disabled", rule.Name())
+		}
+	}
+
+	if _, err := Select("nope", ""); err == nil || err.Error() != `unknown lint rule "nope"` {
+		t.Errorf("expected an unknown rule error, got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package lint
+
+import (
+	"interpreter/ast"
+	"interpreter/printer"
+)
+
+// unused reports let bindings that are never referenced
+
+type unusedRule struct{}
+
+func (unusedRule) Name() string { return "unused" }
+func (unusedRule) Doc() string  { return "let bindings that are never used" }
+
+func (unusedRule) Check(program *ast.Program, report Reporter) {
+	walkScopes(program, scopeHooks{
+		close: func(s *scope) {
+			for _, b := range s.order {
+				if b.kind == "let" && b.uses == 0 && b.name.Value != "_" {
+					report(b.name, "%s declared and not used", b.name.Value)
+				}
+			}
+		},
+	})
+}
+
+// shadow reports bindings hiding another one with the same name
+
+type shadowRule struct{}
+
+func (shadowRule) Name() string { return "shadow" }
+func (shadowRule) Doc() string  { return "bindings that hide an earlier binding with the same name" }
+
+func (shadowRule) Check(program *ast.Program, report Reporter) {
+	walkScopes(program, scopeHooks{
+		declare: func(s *scope, b *binding) {
+			if previous := s.lookup(b.name.Value); previous != nil {
+				report(b.name, "%s shadows the declaration at %s", b.name.Value, previous.name.Pos())
+			}
+		},
+	})
+}
+
+// unreachable reports statements following a return, throw, break or continue
+
+type unreachableRule struct{}
+
+func (unreachableRule) Name() string { return "unreachable" }
+func (unreachableRule) Doc() string  { return "statements after a return, throw, break or continue" }
+
+func (unreachableRule) Check(program *ast.Program, report Reporter) {
+	check := func(stmts []ast.Statement) {
+		for i, stmt := range stmts[:max(len(stmts)-1, 0)] {
+			switch stmt.(type) {
+			case *ast.ReturnStatement, *ast.ThrowStatement, *ast.BreakStatement, *ast.ContinueStatement:
+				report(stmts[i+1], "unreachable code after %s", stmt.TokenLiteral())
+				return
+			}
+		}
+	}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Program:
+			check(n.Statements)
+		case *ast.BlockStatement:
+			check(n.Statements)
+		}
+		return true
+	})
+}
+
+// constant-condition reports if expressions whose condition never changes
+
+type constantConditionRule struct{}
+
+func (constantConditionRule) Name() string { return "constant-condition" }
+func (constantConditionRule) Doc() string  { return "if conditions made only of literals" }
+
+func (constantConditionRule) Check(program *ast.Program, report Reporter) {
+	ast.Inspect(program, func(node ast.Node) bool {
+		if n, ok := node.(*ast.IfExpression); ok && n.Condition != nil && isConstant(n.Condition) {
+			report(n, "condition %s is constant", printer.String(n.Condition))
+		}
+		return true
+	})
+}
+
+func isConstant(exp ast.Expression) bool {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral, *ast.StringLiteral, *ast.Boolean:
+		return true
+	case *ast.PrefixExpression:
+		return isConstant(exp.Right)
+	case *ast.InfixExpression:
+		return isConstant(exp.Left) && isConstant(exp.Right)
+	default:
+		return false
+	}
+}
+
+// self-comparison reports comparisons of an expression with itself
+
+type selfComparisonRule struct{}
+
+func (selfComparisonRule) Name() string { return "self-comparison" }
+func (selfComparisonRule) Doc() string  { return "comparisons of an expression with itself" }
+
+func (selfComparisonRule) Check(program *ast.Program, report Reporter) {
+	outcome := map[string]string{"==": "true", "!=": "false", "<": "false", ">": "false"}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		n, ok := node.(*ast.InfixExpression)
+		if !ok || n.Left == nil || n.Right == nil {
+			return true
+		}
+
+		// compare the source, String leaves the quotes out of strings
+		result, isComparison := outcome[n.Operator]
+		if isComparison && isPure(n.Left) && printer.String(n.Left) == printer.String(n.Right) {
+			report(n, "%s is always %s", printer.String(n), result)
+		}
+		return true
+	})
+}
+
+// isPure reports whether evaluating exp twice gives the same result,
+// that is it has no calls or assignments in it
+func isPure(exp ast.Expression) bool {
+	pure := true
+
+	ast.Inspect(exp, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.CallExpression, *ast.AssignExpression, *ast.IfExpression, *ast.MacroLiteral:
+			pure = false
+		}
+		return pure
+	})
+
+	return pure
+}
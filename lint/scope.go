@@ -0,0 +1,141 @@
+package lint
+
+import "interpreter/ast"
+
+// Only function and macro bodies get their own scope, blocks of loops and
+// ifs share the scope they appear in.
+
+type binding struct {
+	name *ast.Identifier
+	kind string // let, parameter, loop or catch
+	uses int
+}
+
+type scope struct {
+	outer    *scope
+	bindings map[string]*binding
+	order    []*binding
+}
+
+func (s *scope) lookup(name string) *binding {
+	for ; s != nil; s = s.outer {
+		if b, ok := s.bindings[name]; ok {
+			return b
+		}
+	}
+	return nil
+}
+
+type scopeHooks struct {
+	declare func(s *scope, b *binding) // called before b is added to s
+	close   func(s *scope)
+}
+
+// walkScopes goes through program tracking the bindings in scope and
+// counting how many times each one is used
+func walkScopes(program *ast.Program, hooks scopeHooks) {
+	current := &scope{bindings: map[string]*binding{}}
+	declarations := map[*ast.Identifier]bool{}
+	var stack []ast.Node
+
+	// a let value or a loop iterable still sees the previous binding of
+	// the name, so the declaration waits until that node has been walked
+	after := map[ast.Node]func(){}
+
+	declare := func(name *ast.Identifier, kind string) {
+		if name == nil {
+			return
+		}
+
+		b := &binding{name: name, kind: kind}
+		if hooks.declare != nil {
+			hooks.declare(current, b)
+		}
+
+		current.bindings[name.Value] = b
+		current.order = append(current.order, b)
+		declarations[name] = true
+	}
+
+	declareAfter := func(node ast.Node, name *ast.Identifier, kind string) {
+		if name == nil {
+			return
+		}
+
+		declarations[name] = true
+		if node == nil {
+			declare(name, kind)
+			return
+		}
+		after[node] = func() { declare(name, kind) }
+	}
+
+	closeScope := func() {
+		if hooks.close != nil {
+			hooks.close(current)
+		}
+		current = current.outer
+	}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		if node == nil {
+			top := stack[len(stack)-1]
+			switch top.(type) {
+			case *ast.FunctionLiteral, *ast.MacroLiteral:
+				closeScope()
+			}
+			if declare, ok := after[top]; ok {
+				delete(after, top)
+				declare()
+			}
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, node)
+
+		switch n := node.(type) {
+		case *ast.LetStatement:
+			// only a function sees its own name, so it can call itself
+			if _, ok := n.Value.(*ast.FunctionLiteral); ok {
+				declare(n.Name, "let")
+			} else {
+				declareAfter(n.Value, n.Name, "let")
+			}
+
+		case *ast.ForStatement:
+			declareAfter(n.Iterable, n.Variable, "loop")
+
+		case *ast.TryStatement:
+			// the caught value only exists once the try block has thrown
+			if n.Block != nil {
+				declareAfter(n.Block, n.CatchParameter, "catch")
+			} else {
+				declareAfter(nil, n.CatchParameter, "catch")
+			}
+
+		case *ast.FunctionLiteral:
+			current = &scope{outer: current, bindings: map[string]*binding{}}
+			for _, param := range n.Parameters {
+				declare(param, "parameter")
+			}
+
+		case *ast.MacroLiteral:
+			current = &scope{outer: current, bindings: map[string]*binding{}}
+			for _, param := range n.Parameters {
+				declare(param, "parameter")
+			}
+
+		case *ast.Identifier:
+			if declarations[n] {
+				break
+			}
+			if b := current.lookup(n.Value); b != nil {
+				b.uses++
+			}
+		}
+
+		return true
+	})
+
+	closeScope()
+}
@@ -0,0 +1,261 @@
+package lsp
+
+import (
+	"interpreter/ast"
+	"interpreter/lexer"
+	"interpreter/lint"
+	"interpreter/parser"
+	"interpreter/printer"
+	"interpreter/resolver"
+	"interpreter/token"
+	"interpreter/typecheck"
+	"strings"
+	"unicode/utf8"
+)
+
+// A document is an open file along with what the server knows about it
+type document struct {
+	uri     string
+	text    string
+	lines   []int // byte offset where each line starts
+	program *ast.Program
+	types   *typecheck.Info // nil when the program has syntax errors
+
+	diagnostics []Diagnostic
+}
+
+// analyze parses text and checks it. Names are resolved even when there are
+// syntax errors, so navigation keeps working on the parts that parsed, but
+// type checking and linting need a complete program.
+func analyze(uri, text string) *document {
+	d := &document{uri: uri, text: text, lines: []int{0}, diagnostics: []Diagnostic{}}
+
+	for i, c := range text {
+		if c == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+
+	p := parser.New(lexer.New(text))
+	d.program = p.ParseProgram()
+
+	if errs := p.ParseErrors(); len(errs) > 0 {
+		for _, err := range errs {
+			d.report(err.Pos, severityError, "monkey", "", err.Msg)
+		}
+		resolver.Resolve(d.program, nil)
+		return d
+	}
+
+	info, errs := typecheck.Check(d.program)
+	d.types = info
+	for _, err := range errs {
+		d.report(err.Pos, severityError, "typecheck", "", err.Msg)
+	}
+
+	for _, diag := range lint.Run(d.program, lint.Rules()) {
+		d.report(diag.Pos, severityWarning, "lint", diag.Rule, diag.Message)
+	}
+
+	return d
+}
+
+func (d *document) report(pos token.Position, severity int, source, code, msg string) {
+	// underline the character the problem was found at
+	end := pos.Offset
+	if r, size := utf8.DecodeRuneInString(d.text[min(end, len(d.text)):]); size > 0 && r != '\n' {
+		end += size
+	}
+
+	d.diagnostics = append(d.diagnostics, Diagnostic{
+		Range:    Range{Start: d.position(pos.Offset), End: d.position(end)},
+		Severity: severity,
+		Code:     code,
+		Source:   source,
+		Message:  msg,
+	})
+}
+
+// position converts a byte offset in the text to an LSP position
+func (d *document) position(offset int) Position {
+	offset = max(0, min(offset, len(d.text)))
+
+	line := 0
+	for line+1 < len(d.lines) && d.lines[line+1] <= offset {
+		line++
+	}
+
+	character := 0
+	for _, r := range d.text[d.lines[line]:offset] {
+		character += utf16Len(r)
+	}
+
+	return Position{Line: line, Character: character}
+}
+
+// offset converts an LSP position to a byte offset in the text
+func (d *document) offset(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(d.lines) {
+		return len(d.text)
+	}
+
+	offset := d.lines[pos.Line]
+	for character := 0; character < pos.Character && offset < len(d.text); {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		if r == '\n' {
+			break
+		}
+		character += utf16Len(r)
+		offset += size
+	}
+
+	return offset
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+func (d *document) identRange(ident *ast.Identifier) Range {
+	start := ident.Token.Pos.Offset
+	return Range{Start: d.position(start), End: d.position(start + len(ident.Value))}
+}
+
+// identifierAt returns the identifier under pos, nil if there is none
+func (d *document) identifierAt(pos Position) *ast.Identifier {
+	offset := d.offset(pos)
+
+	var found *ast.Identifier
+	ast.Inspect(d.program, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Identifier); ok {
+			start := ident.Token.Pos.Offset
+			if start <= offset && offset <= start+len(ident.Value) {
+				found = ident
+			}
+		}
+		return found == nil
+	})
+
+	return found
+}
+
+// references returns every identifier naming the same declaration as ident
+func (d *document) references(ident *ast.Identifier) []*ast.Identifier {
+	if ident.Symbol == nil || ident.Symbol.Decl == nil {
+		return nil
+	}
+
+	var refs []*ast.Identifier
+	ast.Inspect(d.program, func(node ast.Node) bool {
+		if other, ok := node.(*ast.Identifier); ok && other.Symbol != nil && other.Symbol.Decl == ident.Symbol.Decl {
+			refs = append(refs, other)
+		}
+		return true
+	})
+
+	return refs
+}
+
+// hover describes what ident refers to, like (global) x: int
+func (d *document) hover(ident *ast.Identifier) string {
+	var out strings.Builder
+
+	if ident.Symbol != nil {
+		out.WriteString("(" + strings.ToLower(string(ident.Symbol.Scope)) + ") ")
+	}
+	out.WriteString(ident.Value)
+
+	if d.types != nil {
+		if t, ok := d.types.Types[ident]; ok {
+			out.WriteString(": " + t.String())
+		}
+	}
+
+	return out.String()
+}
+
+// symbols returns the let bindings in node, nesting the ones declared in
+// the value of another one under it
+func (d *document) symbols(node ast.Node) []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+
+	ast.Inspect(node, func(node ast.Node) bool {
+		let, ok := node.(*ast.LetStatement)
+		if !ok || let.Name == nil {
+			return true
+		}
+
+		symbol := DocumentSymbol{
+			Name:           let.Name.Value,
+			Kind:           symbolVariable,
+			Range:          Range{Start: d.position(let.Pos().Offset), End: d.position(end(let))},
+			SelectionRange: d.identRange(let.Name),
+		}
+
+		switch let.Value.(type) {
+		case *ast.FunctionLiteral, *ast.MacroLiteral:
+			symbol.Kind = symbolFunction
+		}
+
+		if d.types != nil {
+			if t, ok := d.types.Types[let.Name]; ok {
+				symbol.Detail = t.String()
+			}
+		}
+
+		if let.Value != nil {
+			if children := d.symbols(let.Value); len(children) > 0 {
+				symbol.Children = children
+			}
+		}
+
+		symbols = append(symbols, symbol)
+		return false
+	})
+
+	return symbols
+}
+
+// end returns the byte offset just past the last token of node that has a
+// node of its own, closing delimiters are not part of the AST
+func end(node ast.Node) int {
+	last := 0
+
+	ast.Inspect(node, func(node ast.Node) bool {
+		if node == nil {
+			return true
+		}
+
+		length := len(node.TokenLiteral())
+		if _, ok := node.(*ast.StringLiteral); ok {
+			length += 2 // the quotes
+		}
+
+		last = max(last, node.Pos().Offset+length)
+		return true
+	})
+
+	return last
+}
+
+// format returns the edits turning the text into its formatted version, nil
+// when the program does not parse
+func (d *document) format() []TextEdit {
+	formatted, err := printer.Source([]byte(d.text))
+	if err != nil {
+		return nil
+	}
+
+	if string(formatted) == d.text {
+		return []TextEdit{}
+	}
+
+	whole := Range{Start: d.position(0), End: d.position(len(d.text))}
+	return []TextEdit{{Range: whole, NewText: string(formatted)}}
+}
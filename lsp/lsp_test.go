@@ -0,0 +1,370 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// client drives a Server running in the same process through pipes
+type client struct {
+	t      *testing.T
+	out    io.WriteCloser
+	in     *bufio.Reader
+	nextID int
+	done   chan error
+
+	// notifications received while waiting for a response
+	diagnostics []PublishDiagnosticsParams
+}
+
+func newClient(t *testing.T) *client {
+	t.Helper()
+
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &client{t: t, out: clientOut, in: bufio.NewReader(clientIn), done: make(chan error, 1)}
+
+	go func() {
+		err := NewServer(serverIn, serverOut).Serve()
+		serverOut.Close()
+		c.done <- err
+	}()
+
+	var result InitializeResult
+	c.call("initialize", map[string]any{"capabilities": map[string]any{}}, &result)
+	if result.ServerInfo.Name != "monkey" || !result.Capabilities.HoverProvider {
+		t.Fatalf("unexpected initialize result %+v", result)
+	}
+	c.notify("initialized", map[string]any{})
+
+	return c
+}
+
+func (c *client) send(msg *message) {
+	c.t.Helper()
+
+	if err := writeMessage(c.out, msg); err != nil {
+		c.t.Fatalf("write failed: %s", err)
+	}
+}
+
+func (c *client) notify(method string, params any) {
+	c.t.Helper()
+
+	data, _ := json.Marshal(params)
+	c.send(&message{Method: method, Params: data})
+}
+
+// call sends a request and decodes its result into result, returning the
+// error the server replied with
+func (c *client) call(method string, params any, result any) *responseError {
+	c.t.Helper()
+
+	c.nextID++
+	id := json.RawMessage(strconv.Itoa(c.nextID))
+	data, _ := json.Marshal(params)
+	c.send(&message{ID: &id, Method: method, Params: data})
+
+	for {
+		msg := c.read()
+
+		if msg.Method == "textDocument/publishDiagnostics" {
+			c.collect(msg)
+			continue
+		}
+
+		if string(*msg.ID) != string(id) {
+			c.t.Fatalf("response to %s has id %s, expected %s", method, *msg.ID, id)
+		}
+
+		if msg.Error != nil {
+			return msg.Error
+		}
+
+		if err := json.Unmarshal(msg.Result, result); err != nil {
+			c.t.Fatalf("cannot decode result of %s: %s", method, err)
+		}
+		return nil
+	}
+}
+
+// waitDiagnostics returns the next diagnostics published for uri
+func (c *client) waitDiagnostics(uri string) []Diagnostic {
+	c.t.Helper()
+
+	for {
+		for i, params := range c.diagnostics {
+			if params.URI == uri {
+				c.diagnostics = append(c.diagnostics[:i], c.diagnostics[i+1:]...)
+				return params.Diagnostics
+			}
+		}
+
+		msg := c.read()
+		if msg.Method != "textDocument/publishDiagnostics" {
+			c.t.Fatalf("unexpected message %+v", msg)
+		}
+		c.collect(msg)
+	}
+}
+
+func (c *client) collect(msg *message) {
+	var params PublishDiagnosticsParams
+	json.Unmarshal(msg.Params, &params)
+	c.diagnostics = append(c.diagnostics, params)
+}
+
+func (c *client) read() *message {
+	c.t.Helper()
+
+	msg, err := readMessage(c.in)
+	if err != nil {
+		c.t.Fatalf("read failed: %s", err)
+	}
+	return msg
+}
+
+func (c *client) open(uri, text string) []Diagnostic {
+	c.t.Helper()
+
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "monkey", Version: 1, Text: text},
+	})
+	return c.waitDiagnostics(uri)
+}
+
+func (c *client) close() error {
+	c.t.Helper()
+
+	var result any
+	if err := c.call("shutdown", nil, &result); err != nil {
+		c.t.Fatalf("shutdown failed: %s", err)
+	}
+	c.notify("exit", nil)
+
+	select {
+	case err := <-c.done:
+		return err
+	case <-time.After(5 * time.Second):
+		c.t.Fatalf("server did not exit")
+		return nil
+	}
+}
+
+func position(line, character int, uri string) TextDocumentPositionParams {
+	return TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: uri}, Position: Position{line, character}}
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newClient(t)
+
+	tests := []struct {
+		text     string
+		expected []string // line:character source: message
+	}{
+		{"let x = 1;\nx;", nil},
+		{"let x = ;\nlet y 2;", []string{
+			"0:8 monkey: No prefix parse function for token type ;",
+			"1:6 monkey: Expected next token to be =, got INT instead",
+		}},
+		{"let s = \"é\" + @;", []string{"0:14 monkey: unexpected character '@'"}},
+		{"let x: int = \"a\";\nlet y = 2;", []string{
+			"0:13 typecheck: cannot use string value as int in let x",
+			"0:4 lint: x declared and not used",
+			"1:4 lint: y declared and not used",
+		}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, d := range c.open("file:///a.mk", tt.text) {
+			got = append(got, strconv.Itoa(d.Range.Start.Line)+":"+strconv.Itoa(d.Range.Start.Character)+" "+d.Source+": "+d.Message)
+		}
+
+		if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+			t.Fatalf("wrong diagnostics for %q\nexpected=%q\ngot=%q", tt.text, tt.expected, got)
+		}
+	}
+
+	if err := c.close(); err != nil {
+		t.Fatalf("server failed: %s", err)
+	}
+}
+
+const source = `let add = fn(a: int, b: int) {
+  let sum = a + b;
+  sum
+};
+let total = add(1, 2);
+add(total, total);
+`
+
+func TestDefinitionAndReferences(t *testing.T) {
+	c := newClient(t)
+	uri := "file:///add.mk"
+	c.open(uri, source)
+
+	tests := []struct {
+		at         Position
+		definition Range
+		references []Range // without the declaration
+	}{
+		// total on the last line
+		{Position{5, 5}, Range{Position{4, 4}, Position{4, 9}}, []Range{
+			{Position{5, 4}, Position{5, 9}},
+			{Position{5, 11}, Position{5, 16}},
+		}},
+		// the parameter a used in the body
+		{Position{1, 12}, Range{Position{0, 13}, Position{0, 14}}, []Range{{Position{1, 12}, Position{1, 13}}}},
+		// add where it is declared
+		{Position{0, 6}, Range{Position{0, 4}, Position{0, 7}}, []Range{
+			{Position{4, 12}, Position{4, 15}},
+			{Position{5, 0}, Position{5, 3}},
+		}},
+	}
+
+	for _, tt := range tests {
+		var location Location
+		c.call("textDocument/definition", position(tt.at.Line, tt.at.Character, uri), &location)
+		if location.URI != uri || location.Range != tt.definition {
+			t.Fatalf("wrong definition at %v, expected=%v, got=%v", tt.at, tt.definition, location)
+		}
+
+		params := ReferenceParams{TextDocumentPositionParams: position(tt.at.Line, tt.at.Character, uri)}
+		var locations []Location
+		c.call("textDocument/references", params, &locations)
+
+		if len(locations) != len(tt.references) {
+			t.Fatalf("wrong references at %v, expected=%v, got=%v", tt.at, tt.references, locations)
+		}
+		for i, loc := range locations {
+			if loc.Range != tt.references[i] {
+				t.Fatalf("wrong reference %d at %v, expected=%v, got=%v", i, tt.at, tt.references[i], loc.Range)
+			}
+		}
+
+		params.Context.IncludeDeclaration = true
+		c.call("textDocument/references", params, &locations)
+		if len(locations) != len(tt.references)+1 {
+			t.Fatalf("expected the declaration among the references, got %v", locations)
+		}
+	}
+
+	// nothing to find on a literal
+	var location *Location
+	c.call("textDocument/definition", position(4, 17, uri), &location)
+	if location != nil {
+		t.Fatalf("expected no definition, got %v", location)
+	}
+
+	c.close()
+}
+
+func TestHover(t *testing.T) {
+	c := newClient(t)
+	uri := "file:///add.mk"
+	c.open(uri, source)
+
+	tests := []struct {
+		at       Position
+		expected string
+	}{
+		{Position{0, 4}, "(global) add: fn(int, int): int"},
+		{Position{1, 7}, "(local) sum: int"},
+		{Position{1, 16}, "(parameter) b: int"},
+		{Position{4, 6}, "(global) total: int"},
+	}
+
+	for _, tt := range tests {
+		var hover Hover
+		c.call("textDocument/hover", position(tt.at.Line, tt.at.Character, uri), &hover)
+
+		expected := "```monkey\n" + tt.expected + "\n```"
+		if hover.Contents.Value != expected {
+			t.Fatalf("wrong hover at %v, expected=%q, got=%q", tt.at, expected, hover.Contents.Value)
+		}
+	}
+
+	c.close()
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	c := newClient(t)
+	uri := "file:///add.mk"
+	c.open(uri, source)
+
+	var symbols []DocumentSymbol
+	c.call("textDocument/documentSymbol", DocumentSymbolParams{TextDocument: TextDocumentIdentifier{URI: uri}}, &symbols)
+
+	var got []string
+	var walk func(prefix string, symbols []DocumentSymbol)
+	walk = func(prefix string, symbols []DocumentSymbol) {
+		for _, s := range symbols {
+			got = append(got, prefix+s.Name+" "+strconv.Itoa(s.Kind)+" "+s.Detail)
+			walk(prefix+s.Name+".", s.Children)
+		}
+	}
+	walk("", symbols)
+
+	expected := []string{"add 12 fn(int, int): int", "add.sum 13 int", "total 13 int"}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("wrong symbols, expected=%q, got=%q", expected, got)
+	}
+
+	if symbols[0].Range.Start != (Position{0, 0}) || symbols[0].Range.End != (Position{2, 5}) {
+		t.Fatalf("wrong range for add, got %v", symbols[0].Range)
+	}
+
+	c.close()
+}
+
+func TestFormatting(t *testing.T) {
+	c := newClient(t)
+	uri := "file:///f.mk"
+	params := DocumentFormattingParams{TextDocument: TextDocumentIdentifier{URI: uri}}
+
+	c.open(uri, "let x=1;\nx+x")
+	var edits []TextEdit
+	c.call("textDocument/formatting", params, &edits)
+
+	if len(edits) != 1 || edits[0].NewText != "let x = 1;\nx + x;\n" || edits[0].Range.End != (Position{1, 3}) {
+		t.Fatalf("unexpected edits %+v", edits)
+	}
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   TextDocumentIdentifier{URI: uri},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "let x = 1;\nx + x;\n"}},
+	})
+	c.waitDiagnostics(uri)
+
+	c.call("textDocument/formatting", params, &edits)
+	if len(edits) != 0 {
+		t.Fatalf("expected no edits for formatted text, got %+v", edits)
+	}
+
+	c.close()
+}
+
+func TestProtocolErrors(t *testing.T) {
+	c := newClient(t)
+
+	var result any
+	if err := c.call("textDocument/unknown", nil, &result); err == nil || err.Code != codeMethodNotFound {
+		t.Fatalf("expected method not found, got %v", err)
+	}
+
+	if err := c.call("textDocument/hover", position(0, 0, "file:///missing.mk"), &result); err == nil || err.Code != codeInvalidParams {
+		t.Fatalf("expected invalid params, got %v", err)
+	}
+
+	c.notify("exit", nil)
+	if err := <-c.done; err != ErrExitWithoutShutdown {
+		t.Fatalf("expected ErrExitWithoutShutdown, got %v", err)
+	}
+}
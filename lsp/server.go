@@ -0,0 +1,298 @@
+// Package lsp implements a Language Server Protocol server for Monkey.
+//
+// The server talks JSON-RPC over a pair of streams, usually stdin and
+// stdout of `monkey lsp`. It keeps the open documents in memory, publishes
+// their syntax, type and lint errors as diagnostics, and answers
+// go-to-definition, find-references, hover, document symbol and formatting
+// requests. Documents are synced whole on every change.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"interpreter/ast"
+	"io"
+)
+
+type Server struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	docs        map[string]*document
+	initialized bool
+	shutdown    bool
+}
+
+// ErrExitWithoutShutdown is returned by Serve when the client asks the
+// server to exit without shutting it down first
+var ErrExitWithoutShutdown = errors.New("lsp: exit without shutdown")
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{in: bufio.NewReader(in), out: out, docs: map[string]*document{}}
+}
+
+type handler func(s *Server, params json.RawMessage) (any, error)
+
+var requests = map[string]handler{
+	"initialize":                  (*Server).initialize,
+	"shutdown":                    (*Server).shutdownRequest,
+	"textDocument/definition":     (*Server).definition,
+	"textDocument/references":     (*Server).references,
+	"textDocument/hover":          (*Server).hover,
+	"textDocument/documentSymbol": (*Server).documentSymbol,
+	"textDocument/formatting":     (*Server).formatting,
+}
+
+var notifications = map[string]func(s *Server, params json.RawMessage) error{
+	"textDocument/didOpen":   (*Server).didOpen,
+	"textDocument/didChange": (*Server).didChange,
+	"textDocument/didClose":  (*Server).didClose,
+}
+
+// Serve handles messages until the client sends exit or closes the input
+func (s *Server) Serve() error {
+	for {
+		msg, err := readMessage(s.in)
+		if err == io.EOF {
+			return nil
+		}
+
+		var rpcErr *responseError
+		if errors.As(err, &rpcErr) {
+			s.reply(nil, nil, rpcErr)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		}
+
+		if msg.ID == nil {
+			s.notify(msg)
+		} else if err := s.request(msg); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) request(msg *message) error {
+	handle, ok := requests[msg.Method]
+
+	switch {
+	case !ok:
+		return s.reply(msg.ID, nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method})
+	case !s.initialized && msg.Method != "initialize":
+		return s.reply(msg.ID, nil, &responseError{Code: codeServerNotInitialized, Message: "server not initialized"})
+	case s.shutdown:
+		return s.reply(msg.ID, nil, &responseError{Code: codeInvalidRequest, Message: "server is shutting down"})
+	}
+
+	result, err := handle(s, msg.Params)
+	if err != nil {
+		return s.reply(msg.ID, nil, &responseError{Code: codeInvalidParams, Message: err.Error()})
+	}
+
+	return s.reply(msg.ID, result, nil)
+}
+
+// notify handles a notification, they get no reply so errors are dropped
+func (s *Server) notify(msg *message) {
+	if handle, ok := notifications[msg.Method]; ok && s.initialized && !s.shutdown {
+		handle(s, msg.Params)
+	}
+}
+
+func (s *Server) reply(id *json.RawMessage, result any, rpcErr *responseError) error {
+	msg := &message{ID: id, Error: rpcErr}
+
+	if id == nil {
+		null := json.RawMessage("null")
+		msg.ID = &null
+	}
+
+	if rpcErr == nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		msg.Result = data
+	}
+
+	return writeMessage(s.out, msg)
+}
+
+func (s *Server) send(method string, params any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	return writeMessage(s.out, &message{Method: method, Params: data})
+}
+
+// Lifecycle
+
+func (s *Server) initialize(params json.RawMessage) (any, error) {
+	s.initialized = true
+
+	var result InitializeResult
+	result.ServerInfo.Name = "monkey"
+	result.Capabilities = ServerCapabilities{
+		TextDocumentSync:           syncFull,
+		DefinitionProvider:         true,
+		ReferencesProvider:         true,
+		HoverProvider:              true,
+		DocumentSymbolProvider:     true,
+		DocumentFormattingProvider: true,
+	}
+
+	return result, nil
+}
+
+func (s *Server) shutdownRequest(params json.RawMessage) (any, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+// Document sync
+
+func (s *Server) didOpen(params json.RawMessage) error {
+	var p DidOpenTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+
+	return s.update(p.TextDocument.URI, p.TextDocument.Text)
+}
+
+func (s *Server) didChange(params json.RawMessage) error {
+	var p DidChangeTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+
+	if len(p.ContentChanges) == 0 {
+		return nil
+	}
+
+	return s.update(p.TextDocument.URI, p.ContentChanges[len(p.ContentChanges)-1].Text)
+}
+
+func (s *Server) didClose(params json.RawMessage) error {
+	var p DidCloseTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+
+	delete(s.docs, p.TextDocument.URI)
+
+	// clear the diagnostics of the closed document
+	return s.send("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []Diagnostic{}})
+}
+
+func (s *Server) update(uri, text string) error {
+	doc := analyze(uri, text)
+	s.docs[uri] = doc
+
+	return s.send("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: doc.diagnostics})
+}
+
+// Language features
+
+// identifierAt decodes the position params of a request and returns the
+// document and the identifier they point at, which may be nil
+func (s *Server) identifierAt(params json.RawMessage, p *TextDocumentPositionParams) (*document, *ast.Identifier, error) {
+	if err := json.Unmarshal(params, p); err != nil {
+		return nil, nil, err
+	}
+
+	doc, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown document %s", p.TextDocument.URI)
+	}
+
+	return doc, doc.identifierAt(p.Position), nil
+}
+
+func (s *Server) definition(params json.RawMessage) (any, error) {
+	doc, ident, err := s.identifierAt(params, &TextDocumentPositionParams{})
+	if err != nil || ident == nil || ident.Symbol == nil || ident.Symbol.Decl == nil {
+		return nil, err
+	}
+
+	return Location{URI: doc.uri, Range: doc.identRange(ident.Symbol.Decl)}, nil
+}
+
+func (s *Server) references(params json.RawMessage) (any, error) {
+	var p ReferenceParams
+	doc, ident, err := s.identifierAt(params, &p.TextDocumentPositionParams)
+	if err != nil || ident == nil {
+		return nil, err
+	}
+
+	// the position params are embedded, decode the context too
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	locations := []Location{}
+	for _, ref := range doc.references(ident) {
+		if ref == ident.Symbol.Decl && !p.Context.IncludeDeclaration {
+			continue
+		}
+		locations = append(locations, Location{URI: doc.uri, Range: doc.identRange(ref)})
+	}
+
+	return locations, nil
+}
+
+func (s *Server) hover(params json.RawMessage) (any, error) {
+	doc, ident, err := s.identifierAt(params, &TextDocumentPositionParams{})
+	if err != nil || ident == nil {
+		return nil, err
+	}
+
+	return Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "```monkey\n" + doc.hover(ident) + "\n```"},
+		Range:    doc.identRange(ident),
+	}, nil
+}
+
+func (s *Server) documentSymbol(params json.RawMessage) (any, error) {
+	var p DocumentSymbolParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	doc, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil, fmt.Errorf("unknown document %s", p.TextDocument.URI)
+	}
+
+	return doc.symbols(doc.program), nil
+}
+
+func (s *Server) formatting(params json.RawMessage) (any, error) {
+	var p DocumentFormattingParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	doc, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil, fmt.Errorf("unknown document %s", p.TextDocument.URI)
+	}
+
+	if edits := doc.format(); edits != nil {
+		return edits, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,205 @@
+// Package macro implements the macro expansion phase that runs between
+// parsing and evaluation.
+//
+// A macro is defined with a top level let statement binding a macro literal,
+// and its body quotes the code a call to the macro is replaced with:
+//
+//	let double = macro(x) { quote(unquote(x) * 2); };
+//	double(1 + 2); // expands to (1 + 2) * 2;
+//
+// The arguments of a macro call are not evaluated, unquote(x) splices in
+// the code given as the argument x. Until Monkey has an evaluator, the body
+// of a macro must be a single quote(...) and unquote can only splice macro
+// parameters and literals.
+package macro
+
+import (
+	"fmt"
+	"interpreter/ast"
+	"interpreter/astjson"
+)
+
+// Define removes the macro definitions from the top level of program and
+// returns them by name.
+func Define(program *ast.Program) map[string]*ast.MacroLiteral {
+	macros := map[string]*ast.MacroLiteral{}
+	definitions := []int{}
+
+	for i, stmt := range program.Statements {
+		let, ok := stmt.(*ast.LetStatement)
+		if !ok {
+			continue
+		}
+
+		if macro, ok := let.Value.(*ast.MacroLiteral); ok {
+			macros[let.Name.Value] = macro
+			definitions = append(definitions, i)
+		}
+	}
+
+	// remove backwards so the indexes stay valid
+	for i := len(definitions) - 1; i >= 0; i-- {
+		at := definitions[i]
+		program.Statements = append(program.Statements[:at], program.Statements[at+1:]...)
+	}
+
+	return macros
+}
+
+// maxDepth is how many times the code produced by a macro is expanded
+// again before giving up on a macro that keeps calling itself
+const maxDepth = 100
+
+// Expand replaces every call to one of macros in program by the code the
+// macro quotes. Calls are expanded innermost first, so macro calls can be
+// arguments of other macro calls, and the code a macro produces is expanded
+// in turn, so macros can call other macros.
+func Expand(program ast.Node, macros map[string]*ast.MacroLiteral) (ast.Node, error) {
+	return expand(program, macros, 0)
+}
+
+func expand(program ast.Node, macros map[string]*ast.MacroLiteral, depth int) (ast.Node, error) {
+	var err error
+
+	expanded := ast.Apply(program, nil, func(c *ast.Cursor) bool {
+		call, ok := c.Node().(*ast.CallExpression)
+		if !ok {
+			return true
+		}
+
+		ident, ok := call.Function.(*ast.Identifier)
+		if !ok {
+			return true
+		}
+
+		macro, ok := macros[ident.Value]
+		if !ok {
+			return true
+		}
+
+		if depth == maxDepth {
+			err = fmt.Errorf("%s: macro %s is expanded more than %d times deep", call.Token.Pos, ident.Value, maxDepth)
+			return false
+		}
+
+		var exp ast.Expression
+		if exp, err = expandCall(ident.Value, macro, call); err != nil {
+			return false
+		}
+
+		var result ast.Node
+		if result, err = expand(exp, macros, depth+1); err != nil {
+			return false
+		}
+
+		c.Replace(result)
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return expanded, nil
+}
+
+func expandCall(name string, macro *ast.MacroLiteral, call *ast.CallExpression) (ast.Expression, error) {
+	if len(call.Arguments) != len(macro.Parameters) {
+		return nil, fmt.Errorf("%s: macro %s expects %d arguments, got %d",
+			call.Token.Pos, name, len(macro.Parameters), len(call.Arguments))
+	}
+
+	quoted, err := quotedExpression(name, macro)
+	if err != nil {
+		return nil, err
+	}
+
+	args := map[string]ast.Expression{}
+	for i, param := range macro.Parameters {
+		args[param.Value] = call.Arguments[i]
+	}
+
+	// the macro is expanded again on every call, so work on a copy
+	body, err := clone(quoted)
+	if err != nil {
+		return nil, err
+	}
+
+	result := ast.Apply(body, nil, func(c *ast.Cursor) bool {
+		unquote, ok := c.Node().(*ast.CallExpression)
+		if !ok || !isCallTo(unquote, "unquote") {
+			return true
+		}
+
+		if len(unquote.Arguments) != 1 {
+			err = fmt.Errorf("%s: unquote expects 1 argument, got %d", unquote.Token.Pos, len(unquote.Arguments))
+			return false
+		}
+
+		switch arg := unquote.Arguments[0].(type) {
+		case *ast.Identifier:
+			code, ok := args[arg.Value]
+			if !ok {
+				err = fmt.Errorf("%s: cannot unquote %s, it is not a parameter of macro %s", arg.Token.Pos, arg.Value, name)
+				return false
+			}
+
+			var copied ast.Node
+			if copied, err = clone(code); err != nil {
+				return false
+			}
+			c.Replace(copied)
+
+		case *ast.IntegerLiteral, *ast.StringLiteral:
+			c.Replace(arg)
+
+		default:
+			err = fmt.Errorf("%s: cannot unquote %s, only macro parameters and literals can be unquoted", unquote.Token.Pos, arg)
+			return false
+		}
+
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(ast.Expression), nil
+}
+
+// quotedExpression returns the expression inside the quote(...) making up
+// the body of macro
+func quotedExpression(name string, macro *ast.MacroLiteral) (ast.Expression, error) {
+	if len(macro.Body.Statements) == 1 {
+		var exp ast.Expression
+
+		switch stmt := macro.Body.Statements[0].(type) {
+		case *ast.ExpressionStatement:
+			exp = stmt.Expression
+		case *ast.ReturnStatement:
+			exp = stmt.ReturnValue
+		}
+
+		if call, ok := exp.(*ast.CallExpression); ok && isCallTo(call, "quote") && len(call.Arguments) == 1 {
+			return call.Arguments[0], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s: the body of macro %s must be a single quote(...)", macro.Token.Pos, name)
+}
+
+func isCallTo(call *ast.CallExpression, name string) bool {
+	ident, ok := call.Function.(*ast.Identifier)
+	return ok && ident.Value == name
+}
+
+// clone makes a deep copy of node by taking it through its JSON encoding
+func clone(node ast.Node) (ast.Node, error) {
+	data, err := astjson.Marshal(node)
+	if err != nil {
+		return nil, err
+	}
+
+	return astjson.Unmarshal(data)
+}
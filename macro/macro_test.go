@@ -0,0 +1,163 @@
+package macro
+
+import (
+	"interpreter/ast"
+	"interpreter/lexer"
+	"interpreter/parser"
+	"testing"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+
+	if len(p.Errors()) > 0 {
+		t.Fatalf("parser errors: %q", p.Errors())
+	}
+
+	return program
+}
+
+func TestDefine(t *testing.T) {
+	program := parse(t, `
+	  let number = 1;
+	  let function = number * 2;
+	  let mymacro = macro(x, y) { quote(unquote(x) + unquote(y)); };
+	  let other = macro() { quote(1); };
+	`)
+
+	macros := Define(program)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("wrong number of statements left, got=%d", len(program.Statements))
+	}
+
+	for _, name := range []string{"number", "function"} {
+		if _, ok := macros[name]; ok {
+			t.Errorf("%s should not be defined as a macro", name)
+		}
+	}
+
+	mymacro, ok := macros["mymacro"]
+	if !ok {
+		t.Fatalf("mymacro was not defined")
+	}
+
+	if len(mymacro.Parameters) != 2 || mymacro.Parameters[0].Value != "x" || mymacro.Parameters[1].Value != "y" {
+		t.Fatalf("wrong parameters for mymacro, got=%v", mymacro.Parameters)
+	}
+
+	if mymacro.Body.String() != "quote((unquote(x) + unquote(y)))" {
+		t.Fatalf("wrong body for mymacro, got=%q", mymacro.Body.String())
+	}
+
+	if _, ok := macros["other"]; !ok {
+		t.Fatalf("other was not defined")
+	}
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let infix = macro() { quote(1 + 2); }; infix();`,
+			`(1 + 2)`,
+		},
+		{
+			`let reverse = macro(a, b) { quote(unquote(b) - unquote(a)); }; reverse(2 + 2, 10 - 5);`,
+			`((10 - 5) - (2 + 2))`,
+		},
+		{
+			`let double = macro(x) { return quote(unquote(x) * unquote(2)); }; double(double(y));`,
+			`((y * 2) * 2)`,
+		},
+		{
+			`let twice = macro(x) { quote(unquote(x) + unquote(x)); }; let a = twice(f("s"));`,
+			`let a = (f(s) + f(s));`,
+		},
+		{
+			`let b = macro(y) { quote(unquote(y) + 1) }; let a = macro(x) { quote(b(unquote(x)) * 2) }; a(5);`,
+			`((5 + 1) * 2)`,
+		},
+		{
+			`let swap = macro(a, b) { quote(tmp = unquote(a)); }; notamacro(1); swap(x, y);`,
+			`notamacro(1)(tmp = x)`,
+		},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		macros := Define(program)
+
+		expanded, err := Expand(program, macros)
+		if err != nil {
+			t.Fatalf("input %q - unexpected error: %s", tt.input, err)
+		}
+
+		if expanded.String() != tt.expected {
+			t.Errorf("input %q - wrong expansion.\nexpected=%q\ngot=     %q", tt.input, tt.expected, expanded.String())
+		}
+	}
+}
+
+func TestExpandDoesNotModifyMacro(t *testing.T) {
+	program := parse(t, `let m = macro(x) { quote(unquote(x)); }; m(a); m(b);`)
+	macros := Define(program)
+
+	expanded, err := Expand(program, macros)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if expanded.String() != "ab" {
+		t.Fatalf("wrong expansion, got=%q", expanded.String())
+	}
+
+	if macros["m"].Body.String() != "quote(unquote(x))" {
+		t.Fatalf("macro body was modified, got=%q", macros["m"].Body.String())
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let m = macro(x) { quote(x); }; m(1, 2);`,
+			"1:34: macro m expects 1 arguments, got 2",
+		},
+		{
+			`let m = macro(x) { let y = 1; quote(x); }; m(1);`,
+			"1:9: the body of macro m must be a single quote(...)",
+		},
+		{
+			`let m = macro(x) { quote(unquote(z)); }; m(1);`,
+			"1:34: cannot unquote z, it is not a parameter of macro m",
+		},
+		{
+			`let m = macro(x) { quote(unquote(x + 1)); }; m(1);`,
+			"1:33: cannot unquote (x + 1), only macro parameters and literals can be unquoted",
+		},
+		{
+			`let m = macro(x) { quote(m(unquote(x))); }; m(1);`,
+			"1:27: macro m is expanded more than 100 times deep",
+		},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		macros := Define(program)
+
+		_, err := Expand(program, macros)
+		if err == nil {
+			t.Fatalf("input %q - expected an error", tt.input)
+		}
+
+		if err.Error() != tt.expected {
+			t.Errorf("input %q - wrong error.\nexpected=%q\ngot=     %q", tt.input, tt.expected, err)
+		}
+	}
+}
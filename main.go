@@ -7,14 +7,60 @@ import (
 	"os/user"
 )
 
+const usage = `usage: monkey [command] [arguments]
+
+Without a command monkey starts the REPL. The commands are:
+
+	fmt [-w] [-d] [files]                                    format source
+	ast [-expand] [-optimize] [-json | -dot | -sexpr] [file] print the AST
+	lint [-json] [-enable r] [-disable r] [files]            report suspicious code
+	check [files]                                            check type annotations
+	lsp                                                      serve LSP over stdin and stdout
+	highlight [-html] [file]                                 color source for terminals or HTML
+`
+
 func main() {
-	user, err := user.Current()
-	if err != nil {
-		panic(err)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "fmt":
+			os.Exit(runFmt(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+		case "ast":
+			os.Exit(runAst(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+		case "lint":
+			os.Exit(runLint(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+		case "check":
+			os.Exit(runCheck(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+		case "lsp":
+			os.Exit(runLsp(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+		case "highlight":
+			os.Exit(runHighlight(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+		default:
+			fmt.Fprintf(os.Stderr, "monkey: unknown command %q\n", os.Args[1])
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
 	}
 
-	fmt.Printf("Hello %s! This is Monkey programming language!\n", user.Name)
-	fmt.Println("Feel free to type some commands")
+	repl.Start(os.Stdin, os.Stdout, repl.Config{Greeting: greeting()})
+}
+
+// greeting welcomes the user by name when it can be found, minimal
+// containers often have no entry for the current user
+func greeting() string {
+	name := ""
+	if u, err := user.Current(); err == nil {
+		name = u.Name
+		if name == "" {
+			name = u.Username
+		}
+	}
+	if name == "" {
+		name = os.Getenv("USER")
+	}
 
-	repl.Start(os.Stdin, os.Stdout)
+	hello := "Hello! "
+	if name != "" {
+		hello = fmt.Sprintf("Hello %s! ", name)
+	}
+	return hello + "This is Monkey programming language!\nFeel free to type some commands"
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	var numbers []string
+	for i := 1; i <= 20; i++ {
+		numbers = append(numbers, fmt.Sprint(i))
+	}
+	lines := func(replace map[int]string) string {
+		var out strings.Builder
+		for i, n := range numbers {
+			if r, ok := replace[i+1]; ok {
+				n = r
+			}
+			out.WriteString(n + "\n")
+		}
+		return out.String()
+	}
+
+	tests := []struct {
+		a, b     string
+		expected string
+	}{
+		{"1\n2\n", "1\n2\n", ""},
+		{
+			// six unchanged lines between changes share one hunk
+			lines(nil), lines(map[int]string{2: "two", 9: "nine"}),
+			"@@ -1,12 +1,12 @@\n 1\n-2\n+two\n 3\n 4\n 5\n 6\n 7\n 8\n-9\n+nine\n 10\n 11\n 12\n",
+		},
+		{
+			// seven do not
+			lines(nil), lines(map[int]string{2: "two", 10: "ten"}),
+			"@@ -1,5 +1,5 @@\n 1\n-2\n+two\n 3\n 4\n 5\n" +
+				"@@ -7,7 +7,7 @@\n 7\n 8\n 9\n-10\n+ten\n 11\n 12\n 13\n",
+		},
+		{"1\n2\n", "1\n", "@@ -1,2 +1,1 @@\n 1\n-2\n"},
+		{"", "1\n2\n", "@@ -0,0 +1,2 @@\n+1\n+2\n"},
+		{"let x = 1;", "let x = 1;\n", "@@ -1,1 +1,1 @@\n-let x = 1;\n\\ No newline at end of file\n+let x = 1;\n"},
+		{
+			"a\nb\nc\nd\ne\nf\ng\nh\ni\nj", "A\nb\nc\nd\ne\nf\ng\nh\ni\nj\n",
+			"@@ -1,4 +1,4 @@\n-a\n+A\n b\n c\n d\n" +
+				"@@ -7,4 +7,4 @@\n g\n h\n i\n-j\n\\ No newline at end of file\n+j\n",
+		},
+	}
+
+	for _, tt := range tests {
+		got := unifiedDiff("f.mk", []byte(tt.a), []byte(tt.b))
+
+		expected := tt.expected
+		if expected != "" {
+			expected = "--- f.mk.orig\n+++ f.mk\n" + expected
+		}
+
+		if got != expected {
+			t.Fatalf("unifiedDiff(%q, %q)\nexpected=%q\ngot=%q", tt.a, tt.b, expected, got)
+		}
+	}
+}
+
+func TestRunFmt(t *testing.T) {
+	dir := t.TempDir()
+	formatted := filepath.Join(dir, "formatted.mk")
+	messy := filepath.Join(dir, "messy.mk")
+	os.WriteFile(formatted, []byte("let x = 1;\n"), 0644)
+	os.WriteFile(messy, []byte("let y=2"), 0600)
+
+	// an old modification time shows whether -w rewrote the file
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	os.Chtimes(formatted, old, old)
+
+	var stdout, stderr strings.Builder
+	if status := runFmt([]string{"-w", formatted, messy}, strings.NewReader(""), &stdout, &stderr); status != 0 {
+		t.Fatalf("fmt -w failed with status %d: %s", status, stderr.String())
+	}
+
+	if info, _ := os.Stat(formatted); !info.ModTime().Equal(old) {
+		t.Fatalf("fmt -w rewrote an already formatted file")
+	}
+
+	src, _ := os.ReadFile(messy)
+	if string(src) != "let y = 2;\n" {
+		t.Fatalf("fmt -w wrote %q", src)
+	}
+	if info, _ := os.Stat(messy); info.Mode().Perm() != 0600 {
+		t.Fatalf("fmt -w changed the permissions to %v", info.Mode().Perm())
+	}
+
+	if stdout.Len() != 0 {
+		t.Fatalf("fmt -w wrote to stdout: %q", stdout.String())
+	}
+}
+
+func TestRunFmtStdin(t *testing.T) {
+	tests := []struct {
+		args   []string
+		status int
+		stdout string
+		stderr string
+	}{
+		{nil, 0, "let x = 1;\n", ""},
+		{[]string{"-d"}, 0, "--- <standard input>.orig\n+++ <standard input>\n@@ -1,1 +1,1 @@\n-let x=1\n+let x = 1;\n", ""},
+		{[]string{"-w"}, 2, "", "monkey fmt: cannot use -w with standard input\n"},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr strings.Builder
+		status := runFmt(tt.args, strings.NewReader("let x=1\n"), &stdout, &stderr)
+
+		if status != tt.status || stdout.String() != tt.stdout || stderr.String() != tt.stderr {
+			t.Fatalf("fmt %q\nexpected=%d %q %q\ngot=%d %q %q",
+				tt.args, tt.status, tt.stdout, tt.stderr, status, stdout.String(), stderr.String())
+		}
+	}
+}
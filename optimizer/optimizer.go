@@ -0,0 +1,213 @@
+// Package optimizer simplifies Monkey programs before they are run.
+//
+// Optimize folds prefix and infix expressions whose operands are integer or
+// boolean literals into a single literal, so 2 * 60 * 60 becomes 7200, and
+// removes the branches of if expressions whose condition folds to a
+// literal. Folding follows the evaluator: integers wrap around on overflow
+// and only false is falsy, so !0 is false.
+package optimizer
+
+import (
+	"fmt"
+	"interpreter/ast"
+	"interpreter/token"
+	"strconv"
+)
+
+// Error is an expression that is certain to fail at runtime
+type Error struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+type optimizer struct {
+	errors []Error
+}
+
+// Optimize rewrites program in place and returns the errors found while
+// folding, such as a division by a constant zero. Expressions with errors
+// are left as they are.
+func Optimize(program *ast.Program) []Error {
+	o := &optimizer{}
+
+	// children are done first, so folded operands and conditions are
+	// literals by the time their parent is looked at
+	ast.Apply(program, nil, func(c *ast.Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.PrefixExpression:
+			if folded := o.foldPrefix(n); folded != nil {
+				c.Replace(folded)
+			}
+
+		case *ast.InfixExpression:
+			if folded := o.foldInfix(n); folded != nil {
+				c.Replace(folded)
+			}
+
+		case *ast.IfExpression:
+			if _, isStatement := c.Parent().(*ast.ExpressionStatement); !isStatement {
+				if taken := takenExpression(n); taken != nil {
+					c.Replace(taken)
+				}
+			}
+
+		case *ast.ExpressionStatement:
+			if n, ok := n.Expression.(*ast.IfExpression); ok {
+				eliminateIfStatement(c, n)
+			}
+		}
+		return true
+	})
+
+	return o.errors
+}
+
+func (o *optimizer) foldPrefix(n *ast.PrefixExpression) ast.Expression {
+	switch right := n.Right.(type) {
+	case *ast.IntegerLiteral:
+		switch n.Operator {
+		case "-":
+			return integer(n.Pos(), -right.Value)
+		case "!":
+			return boolean(n.Pos(), false)
+		}
+
+	case *ast.Boolean:
+		if n.Operator == "!" {
+			return boolean(n.Pos(), !right.Value)
+		}
+	}
+
+	return nil
+}
+
+func (o *optimizer) foldInfix(n *ast.InfixExpression) ast.Expression {
+	if left, right, ok := integers(n.Left, n.Right); ok {
+		pos := n.Left.Pos()
+
+		switch n.Operator {
+		case "+":
+			return integer(pos, left+right)
+		case "-":
+			return integer(pos, left-right)
+		case "*":
+			return integer(pos, left*right)
+		case "/":
+			if right == 0 {
+				o.errors = append(o.errors, Error{Pos: n.Token.Pos, Msg: fmt.Sprintf("division by zero in %s", n)})
+				return nil
+			}
+			return integer(pos, left/right)
+		case "<":
+			return boolean(pos, left < right)
+		case ">":
+			return boolean(pos, left > right)
+		case "==":
+			return boolean(pos, left == right)
+		case "!=":
+			return boolean(pos, left != right)
+		}
+	}
+
+	left, lok := n.Left.(*ast.Boolean)
+	right, rok := n.Right.(*ast.Boolean)
+	if lok && rok {
+		switch n.Operator {
+		case "==":
+			return boolean(left.Pos(), left.Value == right.Value)
+		case "!=":
+			return boolean(left.Pos(), left.Value != right.Value)
+		}
+	}
+
+	return nil
+}
+
+func integers(left, right ast.Expression) (int64, int64, bool) {
+	l, lok := left.(*ast.IntegerLiteral)
+	r, rok := right.(*ast.IntegerLiteral)
+	if !lok || !rok {
+		return 0, 0, false
+	}
+	return l.Value, r.Value, true
+}
+
+// takenBranch returns the block n runs and whether its condition is a literal
+func takenBranch(n *ast.IfExpression) (*ast.BlockStatement, bool) {
+	cond, ok := n.Condition.(*ast.Boolean)
+	if !ok {
+		return nil, false
+	}
+
+	if cond.Value {
+		return n.Consequence, true
+	}
+	return n.Alternative, true
+}
+
+// takenExpression returns what an if with a literal condition used as a
+// value reduces to, when the branch taken is a single expression
+func takenExpression(n *ast.IfExpression) ast.Expression {
+	block, ok := takenBranch(n)
+	if !ok || block == nil || len(block.Statements) != 1 {
+		return nil
+	}
+
+	if stmt, ok := block.Statements[0].(*ast.ExpressionStatement); ok {
+		return stmt.Expression
+	}
+	return nil
+}
+
+// eliminateIfStatement replaces an if statement with a literal condition by
+// the statements of the branch it takes. Monkey has no block scope, so the
+// statements mean the same outside of the block.
+func eliminateIfStatement(c *ast.Cursor, n *ast.IfExpression) {
+	block, ok := takenBranch(n)
+	if !ok {
+		return
+	}
+
+	var stmts []ast.Statement
+	if block != nil {
+		stmts = block.Statements
+	}
+
+	// a block ending with an if has the value of the if, keep the if when
+	// removing it would give the block the value of the previous statement
+	if len(stmts) == 0 && isLast(c) {
+		return
+	}
+
+	for _, stmt := range stmts {
+		c.InsertBefore(stmt)
+	}
+	c.Delete()
+}
+
+func isLast(c *ast.Cursor) bool {
+	switch parent := c.Parent().(type) {
+	case *ast.Program:
+		return c.Index() == len(parent.Statements)-1
+	case *ast.BlockStatement:
+		return c.Index() == len(parent.Statements)-1
+	}
+	return false
+}
+
+func integer(pos token.Position, value int64) *ast.IntegerLiteral {
+	literal := strconv.FormatInt(value, 10)
+	return &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Literal: literal, Pos: pos}, Value: value}
+}
+
+func boolean(pos token.Position, value bool) *ast.Boolean {
+	tok := token.Token{Type: token.FALSE, Literal: "false", Pos: pos}
+	if value {
+		tok = token.Token{Type: token.TRUE, Literal: "true", Pos: pos}
+	}
+	return &ast.Boolean{Token: tok, Value: value}
+}
@@ -0,0 +1,78 @@
+package optimizer
+
+import (
+	"interpreter/lexer"
+	"interpreter/parser"
+	"interpreter/printer"
+	"strings"
+	"testing"
+)
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2 * 60 * 60;", "7200;\n"},
+		{"-5; !true; !!false; !0;", "-5;\nfalse;\nfalse;\nfalse;\n"},
+		{"1 + 2 * x;", "1 + 2 * x;\n"},
+		{"x * (3 - 1);", "x * 2;\n"},
+		{"7 / 2; 10 - 20;", "3;\n-10;\n"},
+		{"1 < 2; 3 > 4; 5 == 5; 5 != 5;", "true;\nfalse;\ntrue;\nfalse;\n"},
+		{"true == false; true != false; true == 1;", "false;\ntrue;\ntrue == 1;\n"},
+		{"let a = if (1 < 2) { 10 } else { 20 };", "let a = 10;\n"},
+		{"let a = if (false) { 10 } else { 2 * 10 };", "let a = 20;\n"},
+		{"let a = if (false) { 10 };", "let a = if (false) {\n\t10;\n};\n"},
+		{"if (true) { let a = 1; a; } else { b; } c;", "let a = 1;\na;\nc;\n"},
+		{"if (false) { a; } c;", "c;\n"},
+		{"if (!true) { a; } else { b; } c;", "b;\nc;\n"},
+		{"c; if (false) { a; }", "c;\nif (false) {\n\ta;\n}\n"},
+		{"while (x) { if (1 > 2) { break; } y; }", "while (x) {\n\ty;\n}\n"},
+		{"if (x) { 1 + 1 }", "if (x) {\n\t2;\n}\n"},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser errors for %q: %v", tt.input, p.Errors())
+		}
+
+		if errs := Optimize(program); len(errs) != 0 {
+			t.Fatalf("unexpected errors for %q: %v", tt.input, errs)
+		}
+
+		if got := printer.String(program); got != tt.expected {
+			t.Fatalf("wrong result for %q\nexpected=%q\ngot=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestOptimizeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+		output   string
+	}{
+		{"10 / 0;", []string{"1:4: division by zero in (10 / 0)"}, "10 / 0;\n"},
+		{"let a = 1 + 10 / (5 - 5);", []string{"1:16: division by zero in (10 / 0)"}, "let a = 1 + 10 / 0;\n"},
+		{"x / 0;", nil, "x / 0;\n"},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+
+		var got []string
+		for _, err := range Optimize(program) {
+			got = append(got, err.Error())
+		}
+
+		if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+			t.Fatalf("wrong errors for %q\nexpected=%q\ngot=%q", tt.input, tt.expected, got)
+		}
+
+		if output := printer.String(program); output != tt.output {
+			t.Fatalf("wrong result for %q\nexpected=%q\ngot=%q", tt.input, tt.output, output)
+		}
+	}
+}
@@ -59,6 +59,33 @@ func TestParserErrors(t *testing.T) {
 	}
 }
 
+func TestLexicalErrors(t *testing.T) {
+	input := `
+	  let x = 5 @ 3;
+	  let y = 12ab;
+	`
+
+	lex := lexer.New(input)
+	p := New(lex)
+
+	p.ParseProgram()
+
+	expected := []string{
+		"2:14: unexpected character '@'",
+		`3:12: malformed number "12ab"`,
+	}
+
+	if len(p.Errors()) != len(expected) {
+		t.Fatalf("expected %d errors, got %d: %q", len(expected), len(p.Errors()), p.Errors())
+	}
+
+	for i, msg := range expected {
+		if p.Errors()[i] != msg {
+			t.Errorf("errors[%d] wrong. expected=%q, got=%q", i, msg, p.Errors()[i])
+		}
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	input := `
 	  return 5;
@@ -248,6 +275,445 @@ func TestParsingInfixExpressions(t *testing.T) {
 	}
 }
 
+func TestOperatorPrecedenceParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"-a * b", "((-a) * b)"},
+		{"a + b * c - d", "((a + (b * c)) - d)"},
+		{"(a + b) * c", "((a + b) * c)"},
+		{"-(5 + 5)", "(-(5 + 5))"},
+		{"a < b == b > a", "((a < b) == (b > a))"},
+		{"let x = (1 + 2) * 3;", "let x = ((1 + 2) * 3);"},
+		{"return a / (b - c);", "return (a / (b - c));"},
+		{"a + add(b * c) + d", "((a + add((b * c))) + d)"},
+		{"add(a, b, 1, 2 * 3, 4 + 5, add(6, 7 * 8))", "add(a, b, 1, (2 * 3), (4 + 5), add(6, (7 * 8)))"},
+		{"f()[0] = g(h)", "((f()[0]) = g(h))"},
+		{"true", "true"},
+		{"3 > 5 == false", "((3 > 5) == false)"},
+		{"!(true == true)", "(!(true == true))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		checkParseErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestIfExpression(t *testing.T) {
+	tests := []struct {
+		input          string
+		hasAlternative bool
+	}{
+		{`if (x < y) { x }`, false},
+		{`if (x < y) { x } else { y }`, true},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		checkParseErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("Statements number is wrong it should have 1 but has (%d) statements", len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not an *ast.ExpressionStatement, got: %T", program.Statements[0])
+		}
+
+		exp, ok := stmt.Expression.(*ast.IfExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression is not an *ast.IfExpression, got: %T", stmt.Expression)
+		}
+
+		if exp.Condition.String() != "(x < y)" {
+			t.Fatalf("exp.Condition is not (x < y), got: %s", exp.Condition)
+		}
+
+		if exp.Consequence.String() != "x" {
+			t.Fatalf("exp.Consequence is not x, got: %s", exp.Consequence)
+		}
+
+		if tt.hasAlternative != (exp.Alternative != nil) {
+			t.Fatalf("exp.Alternative presence wrong, got: %v", exp.Alternative)
+		}
+	}
+}
+
+func TestFunctionLiteralParsing(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedParams []string
+		expectedBody   string
+	}{
+		{"fn() {};", []string{}, ""},
+		{"fn(x) { x };", []string{"x"}, "x"},
+		{"fn(x, y, z) { return x + y * z; };", []string{"x", "y", "z"}, "return (x + (y * z));"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		checkParseErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		function, ok := stmt.Expression.(*ast.FunctionLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression is not an *ast.FunctionLiteral, got: %T", stmt.Expression)
+		}
+
+		if len(function.Parameters) != len(tt.expectedParams) {
+			t.Fatalf("wrong number of parameters, expected %d, got %d", len(tt.expectedParams), len(function.Parameters))
+		}
+
+		for i, ident := range tt.expectedParams {
+			if function.Parameters[i].Value != ident {
+				t.Errorf("parameter %d is not %s, got %s", i, ident, function.Parameters[i].Value)
+			}
+		}
+
+		if function.Body.String() != tt.expectedBody {
+			t.Errorf("function body is not %q, got %q", tt.expectedBody, function.Body.String())
+		}
+	}
+}
+
+func TestTypeAnnotations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x: int = 5;", "let x: int = 5;"},
+		{"let x = 5;", "let x = 5;"},
+		{"fn(a: int, b: string): bool { a }", "fn(a: int, b: string): bool a"},
+		{"fn(a, b: string) { a }", "fn(a, b: string) a"},
+		{"let f: fn = fn(): int { 1 };", "let f: fn = fn(): int 1;"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestTypeAnnotationErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x: = 5;", "1:8: Expected next token to be IDENT, got = instead"},
+		{"fn(a:) { a }", "1:6: Expected next token to be IDENT, got ) instead"},
+		{"fn(a): { a }", "1:8: Expected next token to be IDENT, got { instead"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("wrong errors for %q, expected first=%q, got=%q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestMacroLiteralParsing(t *testing.T) {
+	input := `macro(x, y) { x + y; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("Statements number is wrong it should have 1 but has (%d) statements", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not an *ast.ExpressionStatement, got: %T", program.Statements[0])
+	}
+
+	macro, ok := stmt.Expression.(*ast.MacroLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression is not an *ast.MacroLiteral, got: %T", stmt.Expression)
+	}
+
+	if len(macro.Parameters) != 2 || macro.Parameters[0].Value != "x" || macro.Parameters[1].Value != "y" {
+		t.Fatalf("macro parameters wrong, got: %v", macro.Parameters)
+	}
+
+	if len(macro.Body.Statements) != 1 || macro.Body.String() != "(x + y)" {
+		t.Fatalf("macro body wrong, got: %q", macro.Body.String())
+	}
+}
+
+func TestMacroBodyIsNotInLoop(t *testing.T) {
+	l := lexer.New(`while (x) { let m = macro() { break; }; }`)
+	p := New(l)
+	p.ParseProgram()
+
+	if len(p.Errors()) != 1 || p.Errors()[0] != "1:31: break outside of a loop" {
+		t.Fatalf("expected a break outside of a loop error, got: %q", p.Errors())
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		operator string
+		expected string
+	}{
+		{"x = 5;", "=", "(x = 5)"},
+		{"x += 5;", "+=", "(x += 5)"},
+		{"x -= y * 2;", "-=", "(x -= (y * 2))"},
+		{"x *= 5;", "*=", "(x *= 5)"},
+		{"x /= 5;", "/=", "(x /= 5)"},
+		{"x = y = 5;", "=", "(x = (y = 5))"},
+		{"arr[0] = 1;", "=", "((arr[0]) = 1)"},
+		{`h["k"] = v;`, "=", "((h[k]) = v)"},
+		{"m[i][j] += 1;", "+=", "(((m[i])[j]) += 1)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		checkParseErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("Statements number is wrong it should have 1 but has (%d) statements", len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statemens[0] is not an *ast.ExpressionStatement, got: %T", program.Statements[0])
+		}
+
+		exp, ok := stmt.Expression.(*ast.AssignExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression is not an *ast.AssignExpression, got: %T", stmt.Expression)
+		}
+
+		if exp.Operator != tt.operator {
+			t.Fatalf("exp.Operator is not %s, got: %s", tt.operator, exp.Operator)
+		}
+
+		if exp.String() != tt.expected {
+			t.Fatalf("exp.String() is not %q, got: %q", tt.expected, exp.String())
+		}
+	}
+}
+
+func TestInvalidAssignTargets(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5 = x;", "1:3: cannot assign to 5"},
+		{"x + y = 3;", "1:7: cannot assign to (x + y)"},
+		{"-x += 1;", "1:4: cannot assign to (-x)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		if len(p.Errors()) != 1 {
+			t.Fatalf("input %q - expected 1 error, got %d: %q", tt.input, len(p.Errors()), p.Errors())
+		}
+
+		if p.Errors()[0] != tt.expected {
+			t.Errorf("input %q - error wrong. expected=%q, got=%q", tt.input, tt.expected, p.Errors()[0])
+		}
+	}
+}
+
+func TestWhileStatement(t *testing.T) {
+	input := `while (x < 10) { x += 1; continue; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("Statements number is wrong it should have 1 but has (%d) statements", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not an *ast.WhileStatement, got: %T", program.Statements[0])
+	}
+
+	if stmt.Condition.String() != "(x < 10)" {
+		t.Fatalf("stmt.Condition is not (x < 10), got: %s", stmt.Condition)
+	}
+
+	if len(stmt.Body.Statements) != 2 {
+		t.Fatalf("body should have 2 statements, got: %d", len(stmt.Body.Statements))
+	}
+
+	if _, ok := stmt.Body.Statements[1].(*ast.ContinueStatement); !ok {
+		t.Fatalf("stmt.Body.Statements[1] is not an *ast.ContinueStatement, got: %T", stmt.Body.Statements[1])
+	}
+}
+
+func TestForStatement(t *testing.T) {
+	input := `for (item in items) { if_found = item; break; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("Statements number is wrong it should have 1 but has (%d) statements", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not an *ast.ForStatement, got: %T", program.Statements[0])
+	}
+
+	if stmt.Variable.Value != "item" {
+		t.Fatalf("stmt.Variable is not item, got: %s", stmt.Variable.Value)
+	}
+
+	if stmt.Iterable.String() != "items" {
+		t.Fatalf("stmt.Iterable is not items, got: %s", stmt.Iterable)
+	}
+
+	if len(stmt.Body.Statements) != 2 {
+		t.Fatalf("body should have 2 statements, got: %d", len(stmt.Body.Statements))
+	}
+
+	if _, ok := stmt.Body.Statements[1].(*ast.BreakStatement); !ok {
+		t.Fatalf("stmt.Body.Statements[1] is not an *ast.BreakStatement, got: %T", stmt.Body.Statements[1])
+	}
+}
+
+func TestLoopErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"break;", "1:1: break outside of a loop"},
+		{"while (x) { } continue;", "1:15: continue outside of a loop"},
+		{"for (x items) { }", "1:8: Expected next token to be IN, got IDENT instead"},
+		{"while (x) { x", "1:14: Expected next token to be }, got EOF instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		if len(p.Errors()) == 0 {
+			t.Fatalf("input %q - expected errors, got none", tt.input)
+		}
+
+		if p.Errors()[0] != tt.expected {
+			t.Errorf("input %q - error wrong. expected=%q, got=%q", tt.input, tt.expected, p.Errors()[0])
+		}
+	}
+}
+
+func TestTryStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`try { f(); } catch (e) { log(e); }`, "try f() catch(e) log(e)"},
+		{`try { f(); } finally { close(); }`, "try f() finally close()"},
+		{`try { f(); } catch (e) { throw e; } finally { close(); }`, "try f() catch(e) throw e; finally close()"},
+		{`while (x) { try { break; } finally { x = false; } }`, "whilex try break; finally (x = false)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		checkParseErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("Statements number is wrong it should have 1 but has (%d) statements", len(program.Statements))
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("input %q - expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestThrowStatement(t *testing.T) {
+	l := lexer.New(`throw "bad input: " + x;`)
+	p := New(l)
+	program := p.ParseProgram()
+
+	checkParseErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ThrowStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not an *ast.ThrowStatement, got: %T", program.Statements[0])
+	}
+
+	if stmt.Value.String() != "(bad input:  + x)" {
+		t.Fatalf("stmt.Value is not (bad input:  + x), got: %s", stmt.Value)
+	}
+}
+
+func TestTryErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"try { f(); }", "1:1: try without catch or finally"},
+		{"try { f(); } catch { }", "1:20: Expected next token to be (, got { instead"},
+		{"try { f(); } catch (1) { }", "1:21: Expected next token to be IDENT, got INT instead"},
+		{"try f(); finally { }", "1:5: Expected next token to be {, got IDENT instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		if len(p.Errors()) == 0 {
+			t.Fatalf("input %q - expected errors, got none", tt.input)
+		}
+
+		if p.Errors()[0] != tt.expected {
+			t.Errorf("input %q - error wrong. expected=%q, got=%q", tt.input, tt.expected, p.Errors()[0])
+		}
+	}
+}
+
 func testLetStatement(t *testing.T, s ast.Statement, name string) bool {
 	if s.TokenLiteral() != "let" {
 		t.Errorf("s.TokenLiteral not 'let, got: %q", s.TokenLiteral())
@@ -0,0 +1,346 @@
+// Package printer turns an AST back into canonical Monkey source:
+// one statement per line, blocks indented with a tab and only the
+// parentheses the parser needs to rebuild the same tree.
+package printer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"interpreter/ast"
+	"interpreter/lexer"
+	"interpreter/parser"
+	"interpreter/token"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// precedence of expressions that never need parentheses around them
+const atom = parser.INDEX + 1
+
+// Source parses src and returns it formatted,
+// src is left untouched when it has syntax errors.
+func Source(src []byte) ([]byte, error) {
+	p := parser.New(lexer.New(string(src)))
+	program := p.ParseProgram()
+
+	if len(p.Errors()) > 0 {
+		return nil, errors.New(strings.Join(p.Errors(), "\n"))
+	}
+
+	pr := &printer{ends: statementEnds(string(src), program)}
+	pr.statements(program.Statements)
+
+	return pr.out.Bytes(), nil
+}
+
+// statementEnds returns the line on which each statement of program ends in
+// src, for the statements followed by another one: the line of the last
+// token before the next statement starts.
+func statementEnds(src string, program *ast.Program) map[ast.Statement]int {
+	tokens, _ := lexer.Tokenize(src)
+	ends := map[ast.Statement]int{}
+
+	record := func(stmts []ast.Statement) {
+		for i := 0; i+1 < len(stmts); i++ {
+			next := stmts[i+1].Pos().Offset
+			j := sort.Search(len(tokens), func(j int) bool { return tokens[j].Pos.Offset >= next })
+			if j > 0 {
+				last := tokens[j-1]
+				ends[stmts[i]] = last.Pos.Line + strings.Count(last.Literal, "\n")
+			}
+		}
+	}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Program:
+			record(n.Statements)
+		case *ast.BlockStatement:
+			record(n.Statements)
+		}
+		return true
+	})
+
+	return ends
+}
+
+// Fprint writes the formatted node to w
+func Fprint(w io.Writer, node ast.Node) error {
+	_, err := io.WriteString(w, String(node))
+	return err
+}
+
+// String returns the formatted node, programs end with a newline. Only
+// Source keeps the blank lines of the original between statements.
+func String(node ast.Node) string {
+	pr := &printer{}
+
+	switch node := node.(type) {
+	case *ast.Program:
+		pr.statements(node.Statements)
+	case ast.Statement:
+		pr.statement(node)
+	case ast.Expression:
+		pr.expression(node, parser.LOWEST)
+	}
+
+	return pr.out.String()
+}
+
+type printer struct {
+	out    bytes.Buffer
+	indent int
+	bareIf bool // whether an if statement can go without its semicolon
+
+	// ends has the source line each statement ends on, only known when
+	// printing from source
+	ends map[ast.Statement]int
+}
+
+func (pr *printer) write(s string) {
+	pr.out.WriteString(s)
+}
+
+func (pr *printer) newline() {
+	pr.write("\n")
+	pr.write(strings.Repeat("\t", pr.indent))
+}
+
+// statements prints one statement per line. A blank line is kept when the
+// original source had some room between two statements.
+func (pr *printer) statements(stmts []ast.Statement) {
+	for i, stmt := range stmts {
+		// an if used as a statement reads better without a semicolon, but
+		// it is needed when the next statement could be parsed as its
+		// continuation, like in `if (x) { a }; (b)`
+		nextIsExpression := false
+		if i+1 < len(stmts) {
+			_, nextIsExpression = stmts[i+1].(*ast.ExpressionStatement)
+		}
+		pr.bareIf = !nextIsExpression
+
+		pr.statement(stmt)
+
+		if end, ok := pr.ends[stmt]; ok && stmts[i+1].Pos().Line > end+1 {
+			pr.write("\n")
+		}
+
+		if pr.indent == 0 {
+			pr.write("\n")
+		} else if i+1 < len(stmts) {
+			pr.newline()
+		}
+	}
+}
+
+func (pr *printer) block(block *ast.BlockStatement) {
+	if len(block.Statements) == 0 {
+		pr.write("{}")
+		return
+	}
+
+	pr.write("{")
+	pr.indent++
+	pr.newline()
+	pr.statements(block.Statements)
+	pr.indent--
+	pr.newline()
+	pr.write("}")
+}
+
+func (pr *printer) statement(stmt ast.Statement) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		pr.write("let ")
+		pr.declared(stmt.Name)
+		pr.write(" = ")
+		pr.expression(stmt.Value, parser.LOWEST)
+		pr.write(";")
+
+	case *ast.ReturnStatement:
+		pr.write("return")
+		if stmt.ReturnValue != nil {
+			pr.write(" ")
+			pr.expression(stmt.ReturnValue, parser.LOWEST)
+		}
+		pr.write(";")
+
+	case *ast.ExpressionStatement:
+		bareIf := pr.bareIf
+		pr.expression(stmt.Expression, parser.LOWEST)
+
+		if _, ok := stmt.Expression.(*ast.IfExpression); !ok || !bareIf {
+			pr.write(";")
+		}
+
+	case *ast.BlockStatement:
+		pr.block(stmt)
+
+	case *ast.WhileStatement:
+		pr.write("while (")
+		pr.expression(stmt.Condition, parser.LOWEST)
+		pr.write(") ")
+		pr.block(stmt.Body)
+
+	case *ast.ForStatement:
+		pr.write("for (")
+		pr.write(stmt.Variable.Value)
+		pr.write(" in ")
+		pr.expression(stmt.Iterable, parser.LOWEST)
+		pr.write(") ")
+		pr.block(stmt.Body)
+
+	case *ast.TryStatement:
+		pr.write("try ")
+		pr.block(stmt.Block)
+		if stmt.Catch != nil {
+			pr.write(" catch (")
+			pr.write(stmt.CatchParameter.Value)
+			pr.write(") ")
+			pr.block(stmt.Catch)
+		}
+		if stmt.Finally != nil {
+			pr.write(" finally ")
+			pr.block(stmt.Finally)
+		}
+
+	case *ast.ThrowStatement:
+		pr.write("throw ")
+		pr.expression(stmt.Value, parser.LOWEST)
+		pr.write(";")
+
+	case *ast.BreakStatement:
+		pr.write("break;")
+
+	case *ast.ContinueStatement:
+		pr.write("continue;")
+
+	default:
+		panic(fmt.Sprintf("printer: unexpected statement %T", stmt))
+	}
+}
+
+// expression prints exp wrapped in parentheses when it binds looser than
+// the context it appears in
+func (pr *printer) expression(exp ast.Expression, context int) {
+	if precedence(exp) < context {
+		pr.write("(")
+		defer pr.write(")")
+	}
+
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		pr.write(exp.Value)
+
+	case *ast.IntegerLiteral:
+		pr.write(strconv.FormatInt(exp.Value, 10))
+
+	case *ast.StringLiteral:
+		pr.write(`"` + exp.Value + `"`)
+
+	case *ast.Boolean:
+		pr.write(strconv.FormatBool(exp.Value))
+
+	case *ast.IfExpression:
+		pr.write("if (")
+		pr.expression(exp.Condition, parser.LOWEST)
+		pr.write(") ")
+		pr.block(exp.Consequence)
+		if exp.Alternative != nil {
+			pr.write(" else ")
+			pr.block(exp.Alternative)
+		}
+
+	case *ast.PrefixExpression:
+		pr.write(exp.Operator)
+		pr.expression(exp.Right, parser.PREFIX)
+
+	case *ast.InfixExpression:
+		// operators are left associative, so an operand of the same
+		// precedence on the right hand side needs parentheses
+		prec := precedence(exp)
+		pr.expression(exp.Left, prec)
+		pr.write(" " + exp.Operator + " ")
+		pr.expression(exp.Right, prec+1)
+
+	case *ast.AssignExpression:
+		pr.expression(exp.Target, parser.ASSIGN+1)
+		pr.write(" " + exp.Operator + " ")
+		pr.expression(exp.Value, parser.ASSIGN)
+
+	case *ast.IndexExpression:
+		// calls and indexing chain from left to right: f(x)[0] or a[0](x)
+		pr.expression(exp.Left, parser.CALL)
+		pr.write("[")
+		pr.expression(exp.Index, parser.LOWEST)
+		pr.write("]")
+
+	case *ast.CallExpression:
+		pr.expression(exp.Function, parser.CALL)
+		pr.write("(")
+		for i, arg := range exp.Arguments {
+			if i > 0 {
+				pr.write(", ")
+			}
+			pr.expression(arg, parser.LOWEST)
+		}
+		pr.write(")")
+
+	case *ast.FunctionLiteral:
+		pr.write("fn")
+		pr.parameters(exp.Parameters)
+		if exp.ReturnType != nil {
+			pr.write(": " + exp.ReturnType.Name)
+		}
+		pr.write(" ")
+		pr.block(exp.Body)
+
+	case *ast.MacroLiteral:
+		pr.write("macro")
+		pr.parameters(exp.Parameters)
+		pr.write(" ")
+		pr.block(exp.Body)
+
+	default:
+		panic(fmt.Sprintf("printer: unexpected expression %T", exp))
+	}
+}
+
+func (pr *printer) parameters(params []*ast.Identifier) {
+	pr.write("(")
+	for i, param := range params {
+		if i > 0 {
+			pr.write(", ")
+		}
+		pr.declared(param)
+	}
+	pr.write(")")
+}
+
+// declared writes a name being declared along with its type annotation
+func (pr *printer) declared(name *ast.Identifier) {
+	pr.write(name.Value)
+	if name.Type != nil {
+		pr.write(": " + name.Type.Name)
+	}
+}
+
+func precedence(exp ast.Expression) int {
+	switch exp := exp.(type) {
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	case *ast.InfixExpression:
+		return parser.Precedence(token.TokenType(exp.Operator))
+	case *ast.AssignExpression:
+		return parser.ASSIGN
+	case *ast.IndexExpression:
+		return parser.INDEX
+	case *ast.CallExpression:
+		return parser.CALL
+	default:
+		return atom
+	}
+}
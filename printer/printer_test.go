@@ -0,0 +1,91 @@
+package printer
+
+import (
+	"interpreter/ast"
+	"interpreter/token"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x=1+2*3", "let x = 1 + 2 * 3;\n"},
+		{"let x = ((1 + 2) * 3);", "let x = (1 + 2) * 3;\n"},
+		{"a - (b - c); (a - b) - c;", "a - (b - c);\na - b - c;\n"},
+		{"-(a + b); -(-a); !(a == b)", "-(a + b);\n--a;\n!(a == b);\n"},
+		{"(a + b)[0]; a[(1 + 2)]", "(a + b)[0];\na[1 + 2];\n"},
+		{"x = (y = 5); x + (y = 1)", "x = y = 5;\nx + (y = 1);\n"},
+		{`h["k"] += 1`, "h[\"k\"] += 1;\n"},
+		{"return;return (5)", "return;\nreturn 5;\n"},
+		{"let a = 1;\n\n\n\nlet b = 2;\nlet c = 3;", "let a = 1;\n\nlet b = 2;\nlet c = 3;\n"},
+		{
+			"while(x<10){x+=1;if_done=x;\n\nfor(i in items){continue}break}",
+			"while (x < 10) {\n\tx += 1;\n\tif_done = x;\n\n\tfor (i in items) {\n\t\tcontinue;\n\t}\n\tbreak;\n}\n",
+		},
+		{"while (x) {}", "while (x) {}\n"},
+		{"if (x) { a; b; }\n\nlet y = 1;", "if (x) {\n\ta;\n\tb;\n}\n\nlet y = 1;\n"},
+		{"let z =\n  5;\nlet w = 2;", "let z = 5;\nlet w = 2;\n"},
+		{"if (x) {\n\ta;\n}\nlet y = \"a\nb\";\n\nz", "if (x) {\n\ta;\n}\nlet y = \"a\nb\";\n\nz;\n"},
+		{
+			"try{f()}catch(e){throw \"f: \"+e}finally{close()}try{g()}finally{}",
+			"try {\n\tf();\n} catch (e) {\n\tthrow \"f: \" + e;\n} finally {\n\tclose();\n}\ntry {\n\tg();\n} finally {}\n",
+		},
+		{"let x:int=1;let f=fn(a:int,b):bool{a==b}", "let x: int = 1;\nlet f = fn(a: int, b): bool {\n\ta == b;\n};\n"},
+		{"let f=fn(a,b){return a+b};fn(){}()", "let f = fn(a, b) {\n\treturn a + b;\n};\nfn() {}();\n"},
+		{"if (x) { if (y) { a } }; (b)", "if (x) {\n\tif (y) {\n\t\ta;\n\t}\n};\nb;\n"},
+		{"if(!true){a}else{b};let x=if(y){1}", "if (!true) {\n\ta;\n} else {\n\tb;\n}\nlet x = if (y) {\n\t1;\n};\n"},
+		{"add(1,2*3)(x)[0]; f()", "add(1, 2 * 3)(x)[0];\nf();\n"},
+		{"let m=macro(a,b){quote(unquote(a)+unquote(b))}", "let m = macro(a, b) {\n\tquote(unquote(a) + unquote(b));\n};\n"},
+	}
+
+	for _, tt := range tests {
+		out, err := Source([]byte(tt.input))
+		if err != nil {
+			t.Fatalf("input %q - unexpected error: %s", tt.input, err)
+		}
+
+		if string(out) != tt.expected {
+			t.Errorf("input %q - wrong output.\nexpected=%q\ngot=     %q", tt.input, tt.expected, out)
+		}
+
+		// formatting formatted source must not change it
+		again, err := Source(out)
+		if err != nil {
+			t.Fatalf("input %q - unexpected error formatting output: %s", tt.input, err)
+		}
+
+		if string(again) != string(out) {
+			t.Errorf("input %q - output is not stable.\nfirst= %q\nsecond=%q", tt.input, out, again)
+		}
+	}
+}
+
+func TestSourceErrors(t *testing.T) {
+	_, err := Source([]byte("let x 5;"))
+	if err == nil {
+		t.Fatal("expected an error for invalid source")
+	}
+
+	if err.Error() != "1:7: Expected next token to be =, got INT instead" {
+		t.Errorf("wrong error, got=%q", err)
+	}
+}
+
+func TestStringBuiltTree(t *testing.T) {
+	// (1 + 2) * 3 built by hand, without positions
+	exp := &ast.InfixExpression{
+		Operator: "*",
+		Left: &ast.InfixExpression{
+			Operator: "+",
+			Left:     &ast.IntegerLiteral{Value: 1},
+			Right:    &ast.IntegerLiteral{Value: 2},
+		},
+		Right: &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "3"}, Value: 3},
+	}
+
+	if got := String(exp); got != "(1 + 2) * 3" {
+		t.Errorf("String() wrong, got=%q", got)
+	}
+}
@@ -0,0 +1,99 @@
+package repl
+
+import (
+	"interpreter/ast"
+	"interpreter/lexer"
+	"interpreter/token"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// complete returns the ways of completing the word before the cursor at
+// pos in line, along with the index in line where that word starts. Words
+// are found with the lexer, candidates are keywords and the names bound in
+// the session, or the meta commands at the start of a line.
+func (s *session) complete(line []rune, pos int) (start int, candidates []string) {
+	before := string(line[:pos])
+
+	if strings.HasPrefix(before, ":") && !strings.Contains(before, " ") {
+		var names []string
+		for name := range commands {
+			names = append(names, name)
+		}
+		return 0, matching(names, before)
+	}
+
+	word := ""
+	start = pos
+
+	// the word is an identifier or a keyword ending right at the cursor
+	var last token.Token
+	for tok := range lexer.New(before).Tokens() {
+		last = tok
+	}
+	_, isKeyword := token.Keywords[last.Literal]
+	touching := strings.TrimRight(before, " \t\n") == before
+
+	switch {
+	case (last.Type == token.IDENT || isKeyword) && last.Pos.Offset+len(last.Literal) == len(before):
+		word = last.Literal
+		start = utf8.RuneCountInString(before[:last.Pos.Offset])
+
+	case touching && (last.Type == token.INT || last.Type == token.STRING || last.Type == token.ILLEGAL):
+		// right after or inside a literal, there is no name to complete
+		return pos, nil
+	}
+
+	names := []string{}
+	for keyword := range token.Keywords {
+		names = append(names, keyword)
+	}
+	names = append(names, s.bindings()...)
+
+	return start, matching(names, word)
+}
+
+// bindings returns the names bound by the top level let statements of the
+// session
+func (s *session) bindings() []string {
+	var names []string
+	for _, stmt := range s.program.Statements {
+		if let, ok := stmt.(*ast.LetStatement); ok && let.Name != nil {
+			names = append(names, let.Name.Value)
+		}
+	}
+	return names
+}
+
+// matching returns the distinct names starting with prefix, sorted
+func matching(names []string, prefix string) []string {
+	seen := map[string]bool{}
+	var found []string
+
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) && !seen[name] {
+			seen[name] = true
+			found = append(found, name)
+		}
+	}
+
+	sort.Strings(found)
+	return found
+}
+
+// commonPrefix returns the longest prefix shared by all of names
+func commonPrefix(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	prefix := names[0]
+	for _, name := range names[1:] {
+		for !strings.HasPrefix(name, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	return prefix
+}
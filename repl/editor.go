@@ -0,0 +1,379 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"interpreter/highlight"
+	"interpreter/lexer"
+	"interpreter/token"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// errInterrupted is returned by readLine when the user presses Ctrl-C
+var errInterrupted = errors.New("interrupted")
+
+// An editor reads lines of input. On a terminal it edits them itself in
+// raw mode, with history and reverse search, elsewhere it just reads them.
+type editor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	terminal bool
+	history  *history
+
+	// raw switches the terminal to raw mode, returning how to switch back
+	raw func() (restore func(), err error)
+
+	// complete returns the completions of the word before pos in line and
+	// where that word starts, nil disables completion
+	complete func(line []rune, pos int) (start int, candidates []string)
+}
+
+func newEditor(in io.Reader, out io.Writer, historyPath string) *editor {
+	e := &editor{in: bufio.NewReader(in), out: out, history: &history{}}
+
+	if f, ok := in.(*os.File); ok && isTerminal(int(f.Fd())) {
+		e.terminal = true
+		e.history = loadHistory(historyPath)
+		e.raw = func() (func(), error) { return makeRaw(int(f.Fd())) }
+	}
+
+	return e
+}
+
+func ctrl(key rune) rune { return key & 0x1f }
+
+const escape = '\x1b'
+
+// line is the state of the line being edited
+type line struct {
+	prompt string
+	buf    []rune
+	pos    int // cursor position in buf
+
+	entry int    // history entry shown, len(entries) for the new line
+	draft []rune // the new line, kept while browsing the history
+}
+
+// readInput reads a whole input, asking for more lines with a continuation
+// prompt while brackets are left open. Ctrl-C drops what was typed so far.
+func (e *editor) readInput(prompt, continuation string) (string, error) {
+	var lines []string
+	current := prompt
+
+	for {
+		text, err := e.readLine(current)
+		if err == errInterrupted {
+			lines = nil
+			current = prompt
+			continue
+		}
+		if err != nil {
+			if err == io.EOF && len(lines) > 0 {
+				break
+			}
+			return "", err
+		}
+
+		lines = append(lines, text)
+		if openBrackets(strings.Join(lines, "\n")) <= 0 {
+			break
+		}
+		current = continuation
+	}
+
+	input := strings.Join(lines, "\n")
+	if e.terminal {
+		e.history.add(input)
+	}
+	return input, nil
+}
+
+// openBrackets returns how many parentheses, braces and brackets of src
+// are left open
+func openBrackets(src string) int {
+	depth := 0
+
+	for tok := range lexer.New(src).Tokens() {
+		switch tok.Type {
+		case token.LPAREN, token.LBRACE, token.LBRACKET:
+			depth++
+		case token.RPAREN, token.RBRACE, token.RBRACKET:
+			depth--
+		}
+	}
+
+	return depth
+}
+
+func (e *editor) readLine(prompt string) (string, error) {
+	if !e.terminal {
+		fmt.Fprint(e.out, prompt)
+
+		text, err := e.in.ReadString('\n')
+		if err != nil && (err != io.EOF || text == "") {
+			return "", err
+		}
+		return strings.TrimRight(text, "\r\n"), nil
+	}
+
+	if e.raw != nil {
+		restore, err := e.raw()
+		if err != nil {
+			return "", err
+		}
+		defer restore()
+	}
+
+	return e.edit(prompt)
+}
+
+// edit runs the line editor, the terminal is expected to be in raw mode
+func (e *editor) edit(prompt string) (string, error) {
+	l := &line{prompt: prompt, entry: len(e.history.entries)}
+	e.refresh(l)
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(l.buf), nil
+
+		case ctrl('C'):
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", errInterrupted
+
+		case ctrl('D'):
+			if len(l.buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			l.delete()
+
+		case 127, ctrl('H'):
+			if l.pos > 0 {
+				l.pos--
+				l.delete()
+			}
+
+		case ctrl('A'):
+			l.pos = 0
+		case ctrl('E'):
+			l.pos = len(l.buf)
+		case ctrl('B'):
+			l.pos = max(l.pos-1, 0)
+		case ctrl('F'):
+			l.pos = min(l.pos+1, len(l.buf))
+		case ctrl('K'):
+			l.buf = l.buf[:l.pos]
+		case ctrl('U'):
+			l.buf = l.buf[l.pos:]
+			l.pos = 0
+		case ctrl('P'):
+			e.browse(l, -1)
+		case ctrl('N'):
+			e.browse(l, 1)
+
+		case '\t':
+			e.completeWord(l)
+
+		case ctrl('R'):
+			submit, err := e.reverseSearch(l)
+			if err != nil {
+				return "", err
+			}
+			if submit {
+				fmt.Fprint(e.out, "\r\n")
+				return string(l.buf), nil
+			}
+
+		case escape:
+			switch e.readEscape() {
+			case "[A", "OA":
+				e.browse(l, -1)
+			case "[B", "OB":
+				e.browse(l, 1)
+			case "[C", "OC":
+				l.pos = min(l.pos+1, len(l.buf))
+			case "[D", "OD":
+				l.pos = max(l.pos-1, 0)
+			case "[H", "OH", "[1~", "[7~":
+				l.pos = 0
+			case "[F", "OF", "[4~", "[8~":
+				l.pos = len(l.buf)
+			case "[3~":
+				l.delete()
+			}
+
+		default:
+			if r >= ' ' {
+				l.insert(r)
+			}
+		}
+
+		e.refresh(l)
+	}
+}
+
+func (l *line) insert(r rune) {
+	l.buf = append(l.buf[:l.pos], append([]rune{r}, l.buf[l.pos:]...)...)
+	l.pos++
+}
+
+// delete removes the rune under the cursor
+func (l *line) delete() {
+	if l.pos < len(l.buf) {
+		l.buf = append(l.buf[:l.pos], l.buf[l.pos+1:]...)
+	}
+}
+
+// completeWord completes the word before the cursor as far as all the
+// candidates agree, and lists them when that adds nothing
+func (e *editor) completeWord(l *line) {
+	if e.complete == nil {
+		return
+	}
+
+	start, candidates := e.complete(l.buf, l.pos)
+	if len(candidates) == 0 {
+		return
+	}
+
+	word := l.buf[start:l.pos]
+	common := []rune(commonPrefix(candidates))
+
+	if len(common) > len(word) {
+		l.buf = append(append(append([]rune{}, l.buf[:start]...), common...), l.buf[l.pos:]...)
+		l.pos = start + len(common)
+		return
+	}
+
+	if len(candidates) > 1 {
+		fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+	}
+}
+
+// browse moves through the history, by -1 to older entries and 1 to newer
+func (e *editor) browse(l *line, by int) {
+	entries := e.history.entries
+
+	next := l.entry + by
+	if next < 0 || next > len(entries) {
+		return
+	}
+
+	if l.entry == len(entries) {
+		l.draft = l.buf
+	}
+
+	l.entry = next
+	if next == len(entries) {
+		l.buf = l.draft
+	} else {
+		l.buf = []rune(entries[next])
+	}
+	l.pos = len(l.buf)
+}
+
+// reverseSearch looks for the typed text through the history, newest
+// first. Ctrl-R finds an older match, Enter runs the match, Ctrl-G gives
+// up, and any other key leaves the match on the line to be edited.
+func (e *editor) reverseSearch(l *line) (submit bool, err error) {
+	var query []rune
+	match := -1
+
+	for {
+		found := ""
+		if match >= 0 {
+			found = e.history.entries[match]
+		}
+		fmt.Fprintf(e.out, "\r(reverse-i-search)`%s': %s\x1b[K", string(query), found)
+
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return false, err
+		}
+
+		switch {
+		case r == ctrl('R'):
+			if match >= 0 {
+				if older := e.history.search(string(query), match); older >= 0 {
+					match = older
+				}
+			}
+			continue
+
+		case r == 127 || r == ctrl('H'):
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+			}
+			match = e.history.search(string(query), len(e.history.entries))
+			continue
+
+		case r == ctrl('G') || r == ctrl('C'):
+			return false, nil
+
+		case r >= ' ':
+			query = append(query, r)
+			match = e.history.search(string(query), len(e.history.entries))
+			continue
+
+		case r == escape:
+			e.readEscape()
+		}
+
+		if match >= 0 {
+			l.buf = []rune(e.history.entries[match])
+			l.pos = len(l.buf)
+		}
+		return (r == '\r' || r == '\n') && match >= 0, nil
+	}
+}
+
+// readEscape reads the rest of an escape sequence, like [A for the up
+// arrow, after the escape character
+func (e *editor) readEscape() string {
+	var seq strings.Builder
+
+	r, _, err := e.in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return ""
+	}
+	seq.WriteRune(r)
+
+	// parameters are digits and semicolons, the sequence ends with a letter or ~
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return ""
+		}
+		seq.WriteRune(r)
+
+		if r >= 0x40 && r <= 0x7e {
+			return seq.String()
+		}
+	}
+}
+
+// refresh redraws the line, colored, and puts the cursor back in place
+func (e *editor) refresh(l *line) {
+	var out strings.Builder
+
+	out.WriteString("\r" + l.prompt)
+	highlight.ANSI(&out, string(l.buf))
+	out.WriteString("\x1b[K\r")
+
+	if column := utf8.RuneCountInString(l.prompt) + l.pos; column > 0 {
+		fmt.Fprintf(&out, "\x1b[%dC", column)
+	}
+
+	io.WriteString(e.out, out.String())
+}
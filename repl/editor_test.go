@@ -0,0 +1,186 @@
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestEditor edits keys as if typed on a terminal
+func newTestEditor(keys string, entries ...string) *editor {
+	return &editor{
+		in:       bufio.NewReader(strings.NewReader(keys)),
+		out:      io.Discard,
+		terminal: true,
+		history:  &history{entries: entries},
+	}
+}
+
+func TestEdit(t *testing.T) {
+	tests := []struct {
+		keys     string
+		history  []string
+		expected string
+	}{
+		{"let x = 1;\r", nil, "let x = 1;"},
+		{"ab\x7fc\r", nil, "ac"},
+		{"bc\x01a\x05d\r", nil, "abcd"},
+		{"ac\x1b[Db\r", nil, "abc"},
+		{"abc\x1b[D\x1b[D\x1b[3~\r", nil, "ac"},
+		{"abcd\x02\x02\x0b\r", nil, "ab"},
+		{"abcd\x02\x02\x15\r", nil, "cd"},
+		{"\x1b[A\r", []string{"old", "new"}, "new"},
+		{"\x1b[A\x1b[A\r", []string{"old", "new"}, "old"},
+		{"draft\x1b[A\x1b[B\r", []string{"old"}, "draft"},
+		{"\x10\x10\x10\x0e\r", []string{"old", "new"}, "new"},
+		{"\x12ol\r", []string{"let old = 1;", "let new = 2;"}, "let old = 1;"},
+		{"\x12let\x12\r", []string{"let old = 1;", "let new = 2;"}, "let old = 1;"},
+		{"\x12new\x05;\r", []string{"let new = 2"}, "let new = 2;"},
+		{"x\x12new\x07\r", []string{"let new = 2"}, "x"},
+		{"é\x1b[Dà\r", nil, "àé"},
+	}
+
+	for _, tt := range tests {
+		got, err := newTestEditor(tt.keys, tt.history...).edit(">> ")
+		if err != nil {
+			t.Fatalf("edit(%q) failed: %s", tt.keys, err)
+		}
+
+		if got != tt.expected {
+			t.Fatalf("edit(%q) expected=%q, got=%q", tt.keys, tt.expected, got)
+		}
+	}
+}
+
+func TestEditCompletion(t *testing.T) {
+	tests := []struct {
+		keys     string
+		expected string
+	}{
+		{"tot\t;\r", "total;"},
+		{"cou\t\r", "count"},
+		{"counte\t\r", "counter"},
+		{"x\t\r", "x"},
+		{"to\x01\t\r", "to"},
+	}
+
+	for _, tt := range tests {
+		ed := newTestEditor(tt.keys)
+		ed.complete = func(line []rune, pos int) (int, []string) {
+			return 0, matching([]string{"counter", "count", "total"}, string(line[:pos]))
+		}
+
+		got, err := ed.edit(">> ")
+		if err != nil || got != tt.expected {
+			t.Fatalf("edit(%q) expected=%q, got=%q (%v)", tt.keys, tt.expected, got, err)
+		}
+	}
+}
+
+func TestEditInterrupts(t *testing.T) {
+	if _, err := newTestEditor("abc\x03").edit(">> "); err != errInterrupted {
+		t.Fatalf("expected errInterrupted on Ctrl-C, got %v", err)
+	}
+
+	if _, err := newTestEditor("\x04").edit(">> "); err != io.EOF {
+		t.Fatalf("expected io.EOF on Ctrl-D, got %v", err)
+	}
+
+	// Ctrl-D deletes when the line is not empty
+	if got, _ := newTestEditor("ab\x01\x04\r").edit(">> "); got != "b" {
+		t.Fatalf("expected b, got %q", got)
+	}
+}
+
+func TestReadInput(t *testing.T) {
+	tests := []struct {
+		keys     string
+		expected []string
+	}{
+		{"1 + 2\r", []string{"1 + 2"}},
+		{"let f = fn(a) {\ra\r};\r", []string{"let f = fn(a) {\na\n};"}},
+		{"add(1,\r2)\rx[\r0]\r", []string{"add(1,\n2)", "x[\n0]"}},
+		{"if (x) {\r\x03y\r", []string{"y"}},
+		{"}\r", []string{"}"}},
+	}
+
+	for _, tt := range tests {
+		ed := newTestEditor(tt.keys)
+
+		var got []string
+		for {
+			input, err := ed.readInput(">> ", ".. ")
+			if err != nil {
+				break
+			}
+			got = append(got, input)
+		}
+
+		if strings.Join(got, "|") != strings.Join(tt.expected, "|") {
+			t.Fatalf("readInput(%q) expected=%q, got=%q", tt.keys, tt.expected, got)
+		}
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HISTORY_FILE)
+
+	h := loadHistory(path)
+	h.add("let a = 1;")
+	h.add("let a = 1;")
+	h.add("   ")
+	h.add("fn(x) {\n  x\n}")
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("history was not saved: %s", err)
+	}
+
+	expected := "let a = 1;\nfn(x) {   x }\n"
+	if string(content) != expected {
+		t.Fatalf("wrong history file, expected=%q, got=%q", expected, content)
+	}
+
+	reloaded := loadHistory(path)
+	if strings.Join(reloaded.entries, "|") != "let a = 1;|fn(x) {   x }" {
+		t.Fatalf("wrong entries loaded: %q", reloaded.entries)
+	}
+}
+
+func TestHistoryFileIsTrimmed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HISTORY_FILE)
+
+	var lines []string
+	for i := 0; i < 2*maxHistory+1; i++ {
+		lines = append(lines, fmt.Sprintf("entry %d", i))
+	}
+	os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+
+	h := loadHistory(path)
+	if len(h.entries) != maxHistory || h.entries[0] != lines[maxHistory+1] {
+		t.Fatalf("wrong entries loaded: %d starting with %q", len(h.entries), h.entries[0])
+	}
+
+	content, _ := os.ReadFile(path)
+	expected := strings.Join(lines[maxHistory+1:], "\n") + "\n"
+	if string(content) != expected {
+		t.Fatalf("history file was not trimmed, it has %d lines", strings.Count(string(content), "\n"))
+	}
+
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Fatalf("trimmed history file has mode %v", info.Mode().Perm())
+	}
+
+	// a file below the limit is left as it is
+	h.add("new")
+	if h = loadHistory(path); len(h.entries) != maxHistory {
+		t.Fatalf("expected %d entries, got %d", maxHistory, len(h.entries))
+	}
+	if content, _ := os.ReadFile(path); strings.Count(string(content), "\n") != maxHistory+1 {
+		t.Fatalf("history file was rewritten below the limit")
+	}
+}
@@ -0,0 +1,107 @@
+package repl
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const maxHistory = 1000
+
+// history holds the inputs entered so far, oldest first
+type history struct {
+	entries []string
+	path    string // file entries are appended to, empty to keep them in memory only
+}
+
+// loadHistory reads the entries saved in path, a missing or unreadable
+// file just means there is no history yet
+func loadHistory(path string) *history {
+	h := &history{path: path}
+	if path == "" {
+		return h
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return h
+	}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+
+	f.Close()
+
+	// add only ever appends to the file, so shrink it back once it has
+	// grown well past what is kept
+	if len(h.entries) > 2*maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+		h.rewrite()
+	}
+
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+
+	return h
+}
+
+// rewrite replaces the file with the entries in memory, through a
+// temporary file so a failure leaves the old one in place
+func (h *history) rewrite() {
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), filepath.Base(h.path)+".*")
+	if err != nil {
+		return
+	}
+
+	_, err = tmp.WriteString(strings.Join(h.entries, "\n") + "\n")
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil || os.Rename(tmp.Name(), h.path) != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// add records entry unless it is blank or repeats the last one. Entries are
+// saved one per line, so inputs spanning several lines are joined.
+func (h *history) add(entry string) {
+	entry = strings.TrimSpace(strings.ReplaceAll(entry, "\n", " "))
+	if entry == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry) {
+		return
+	}
+
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[1:]
+	}
+
+	if h.path == "" {
+		return
+	}
+
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	f.WriteString(entry + "\n")
+}
+
+// search returns the index of the newest entry before from containing
+// query, or -1
+func (h *history) search(query string, from int) int {
+	for i := min(from, len(h.entries)) - 1; i >= 0; i-- {
+		if strings.Contains(h.entries[i], query) {
+			return i
+		}
+	}
+	return -1
+}
@@ -1,32 +1,79 @@
 package repl
 
 import (
-	"bufio"
 	"fmt"
-	"interpreter/lexer"
-	"interpreter/token"
 	"io"
+	"os"
+	"path/filepath"
 )
 
 const PROMPT = ">> "
 
-func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
+// CONTINUATION_PROMPT asks for the rest of an input with open brackets
+const CONTINUATION_PROMPT = ".. "
 
-	for {
-		fmt.Fprintf(out, PROMPT)
-		scanned := scanner.Scan()
+// HISTORY_FILE is where the inputs typed on a terminal are saved, in the
+// home directory
+const HISTORY_FILE = ".monkey_history"
+
+// Config controls how a REPL session talks to the user
+type Config struct {
+	Prompt             string // PROMPT when empty
+	ContinuationPrompt string // CONTINUATION_PROMPT when empty
+	Greeting           string // written once before the first prompt
+
+	// Quiet leaves out the greeting and the prompts, so only results and
+	// errors are written. It is always on when the input is not a terminal.
+	Quiet bool
+}
+
+func Start(in io.Reader, out io.Writer, config Config) {
+	if config.Prompt == "" {
+		config.Prompt = PROMPT
+	}
+	if config.ContinuationPrompt == "" {
+		config.ContinuationPrompt = CONTINUATION_PROMPT
+	}
+
+	historyPath := ""
+	if home, err := os.UserHomeDir(); err == nil {
+		historyPath = filepath.Join(home, HISTORY_FILE)
+	}
 
-		if !scanned {
+	ed := newEditor(in, out, historyPath)
+	s := newSession(out)
+	ed.complete = s.complete
+
+	prompt, continuation := config.Prompt, config.ContinuationPrompt
+	if config.Quiet || !interactive(in, ed) {
+		prompt, continuation = "", ""
+	} else if config.Greeting != "" {
+		fmt.Fprintln(out, config.Greeting)
+	}
+
+	for {
+		input, err := ed.readInput(prompt, continuation)
+		if err != nil {
 			return
 		}
 
-		line := scanner.Text()
-		l := lexer.New(line)
+		s.run(input)
+	}
+}
 
-		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
-			fmt.Fprintf(out, "%+v\n", tok)
-		}
+// interactive reports whether someone is typing the input. Where terminals
+// cannot be detected, any character device is taken for one so the prompts
+// are kept.
+func interactive(in io.Reader, ed *editor) bool {
+	if ed.terminal {
+		return true
+	}
+
+	f, ok := in.(*os.File)
+	if !ok || canDetectTerminal {
+		return false
 	}
 
+	info, err := f.Stat()
+	return err != nil || info.Mode()&os.ModeCharDevice != 0
 }
@@ -0,0 +1,16 @@
+package repl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStartQuiet(t *testing.T) {
+	var out strings.Builder
+	Start(strings.NewReader(":ast\nlet a = fn(x) {\nx\n};\n"), &out, Config{Prompt: "> ", Greeting: "hi"})
+
+	expected := "showing ast\nlet a = fn(x) x;\n"
+	if out.String() != expected {
+		t.Fatalf("expected=%q, got=%q", expected, out.String())
+	}
+}
@@ -0,0 +1,206 @@
+package repl
+
+import (
+	"fmt"
+	"interpreter/ast"
+	"interpreter/astdump"
+	"interpreter/lexer"
+	"interpreter/parser"
+	"interpreter/typecheck"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A session is the state of a REPL run: what inputs are shown as and the
+// program made of every statement entered so far
+type session struct {
+	out     io.Writer
+	mode    string // tokens or ast
+	timing  bool
+	program *ast.Program
+}
+
+func newSession(out io.Writer) *session {
+	return &session{out: out, mode: "tokens", program: &ast.Program{}}
+}
+
+// run handles an input, either a meta command or code
+func (s *session) run(input string) {
+	start := time.Now()
+
+	if strings.HasPrefix(input, ":") {
+		s.command(input)
+	} else {
+		s.code(input)
+	}
+
+	if s.timing {
+		fmt.Fprintf(s.out, "(%s)\n", time.Since(start))
+	}
+}
+
+// code shows input as the current mode asks and adds its statements to the
+// session when it parses
+func (s *session) code(input string) {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+
+	switch s.mode {
+	case "tokens":
+		for tok := range lexer.New(input).Tokens() {
+			fmt.Fprintf(s.out, "%+v\n", tok)
+		}
+
+	case "ast":
+		if s.errors(p) {
+			return
+		}
+		for _, stmt := range program.Statements {
+			fmt.Fprintln(s.out, stmt.String())
+		}
+	}
+
+	if len(p.Errors()) == 0 {
+		s.program.Statements = append(s.program.Statements, program.Statements...)
+	}
+}
+
+// errors prints the errors of p, if any, and reports whether there were some
+func (s *session) errors(p *parser.Parser) bool {
+	for _, msg := range p.Errors() {
+		fmt.Fprintf(s.out, "\t%s\n", msg)
+	}
+	return len(p.Errors()) > 0
+}
+
+type command struct {
+	args string // what follows the command, for :help
+	help string
+	run  func(s *session, arg string)
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		":help":   {"", "list the commands", (*session).help},
+		":tokens": {"", "show the tokens of each input", setMode("tokens")},
+		":ast":    {"", "show the AST of each input", setMode("ast")},
+		":eval":   {"", "show the value of each input", (*session).eval},
+		":env":    {"", "list the bindings of the session", (*session).env},
+		":load":   {"<file>", "add the statements of file to the session", (*session).load},
+		":reset":  {"", "forget the bindings of the session", (*session).reset},
+		":time":   {"", "toggle timing each input", (*session).toggleTiming},
+		":dot":    {"<code>", "print the AST of code as a DOT graph", dump(astdump.Dot)},
+		":sexpr":  {"<code>", "print the AST of code as an S-expression", dump(astdump.SExpr)},
+	}
+}
+
+// command runs a line such as `:sexpr 1 + 2`, made of a colon prefixed
+// command and its argument
+func (s *session) command(line string) {
+	name, arg, _ := strings.Cut(line, " ")
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(s.out, "unknown command %s, try :help\n", name)
+		return
+	}
+
+	cmd.run(s, strings.TrimSpace(arg))
+}
+
+func (s *session) help(string) {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cmd := commands[name]
+		fmt.Fprintf(s.out, "%-16s %s\n", strings.TrimSpace(name+" "+cmd.args), cmd.help)
+	}
+}
+
+func setMode(mode string) func(s *session, arg string) {
+	return func(s *session, arg string) {
+		s.mode = mode
+		fmt.Fprintf(s.out, "showing %s\n", mode)
+	}
+}
+
+func (s *session) eval(string) {
+	fmt.Fprintf(s.out, "Monkey has no evaluator yet, still showing %s\n", s.mode)
+}
+
+// env lists the top level let bindings with their inferred types, the
+// latest one for names bound more than once
+func (s *session) env(string) {
+	info, _ := typecheck.Check(s.program)
+
+	latest := map[string]*ast.LetStatement{}
+	var names []string
+
+	for _, stmt := range s.program.Statements {
+		if let, ok := stmt.(*ast.LetStatement); ok && let.Name != nil {
+			if _, seen := latest[let.Name.Value]; !seen {
+				names = append(names, let.Name.Value)
+			}
+			latest[let.Name.Value] = let
+		}
+	}
+
+	for _, name := range names {
+		let := latest[name]
+		fmt.Fprintf(s.out, "%s: %s = %s\n", name, info.Types[let.Name], let.Value)
+	}
+}
+
+func (s *session) load(path string) {
+	if path == "" {
+		fmt.Fprintln(s.out, "usage: :load <file>")
+		return
+	}
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(s.out, err)
+		return
+	}
+
+	p := parser.New(lexer.New(string(src)))
+	program := p.ParseProgram()
+	if s.errors(p) {
+		return
+	}
+
+	s.program.Statements = append(s.program.Statements, program.Statements...)
+	fmt.Fprintf(s.out, "loaded %d statements from %s\n", len(program.Statements), path)
+}
+
+func (s *session) reset(string) {
+	s.program = &ast.Program{}
+	fmt.Fprintln(s.out, "session reset")
+}
+
+func (s *session) toggleTiming(string) {
+	s.timing = !s.timing
+	fmt.Fprintf(s.out, "timing %s\n", map[bool]string{true: "on", false: "off"}[s.timing])
+}
+
+func dump(print func(io.Writer, ast.Node) error) func(s *session, arg string) {
+	return func(s *session, source string) {
+		p := parser.New(lexer.New(source))
+		program := p.ParseProgram()
+
+		if s.errors(p) {
+			return
+		}
+
+		print(s.out, program)
+	}
+}
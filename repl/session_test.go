@@ -0,0 +1,114 @@
+package repl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSession(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "lib.mk")
+	os.WriteFile(file, []byte("let double = fn(x: int) { x * 2 };\nlet ten = double(5);"), 0644)
+
+	tests := []struct {
+		inputs   []string
+		expected string
+	}{
+		{[]string{":ast", "1 + 2 * 3; let a = -b;"}, "showing ast\n(1 + (2 * 3))\nlet a = (-b);\n"},
+		{[]string{":ast", "let = 1;"}, "showing ast\n\t1:5: Expected next token to be IDENT, got = instead\n\t1:5: No prefix parse function for token type =\n"},
+		{[]string{"let a = 1;", ":ast", "let b = \"s\";", "let a = true;", ":env"}, "showing ast\nlet b = s;\nlet a = true;\na: bool = true\nb: string = s\n"},
+		{[]string{":load " + file, ":env"}, "loaded 2 statements from " + file + "\ndouble: fn(int): int = fn(x: int) (x * 2)\nten: int = double(5)\n"},
+		{[]string{"let a = 1;", ":reset", ":env"}, "session reset\n"},
+		{[]string{":eval"}, "Monkey has no evaluator yet, still showing tokens\n"},
+		{[]string{":nope"}, "unknown command :nope, try :help\n"},
+		{[]string{":load"}, "usage: :load <file>\n"},
+	}
+
+	for _, tt := range tests {
+		var out strings.Builder
+		s := newSession(&out)
+
+		for _, input := range tt.inputs {
+			s.run(input)
+		}
+
+		// tokens of inputs entered before switching modes are not interesting
+		var got strings.Builder
+		for _, line := range strings.SplitAfter(out.String(), "\n") {
+			if !strings.HasPrefix(line, "{Type:") {
+				got.WriteString(line)
+			}
+		}
+
+		if got.String() != tt.expected {
+			t.Fatalf("wrong output for %q\nexpected=%q\ngot=%q", tt.inputs, tt.expected, got.String())
+		}
+	}
+}
+
+func TestTokensMode(t *testing.T) {
+	var out strings.Builder
+	newSession(&out).run("x;")
+
+	expected := "{Type:IDENT Literal:x Pos:1:1}\n{Type:; Literal:; Pos:1:2}\n"
+	if out.String() != expected {
+		t.Fatalf("expected=%q, got=%q", expected, out.String())
+	}
+}
+
+func TestTiming(t *testing.T) {
+	var out strings.Builder
+	s := newSession(&out)
+
+	s.run(":time")
+	s.run(":ast")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 || lines[0] != "timing on" || lines[2] != "showing ast" || !strings.HasPrefix(lines[3], "(") {
+		t.Fatalf("expected a duration after the command, got %q", out.String())
+	}
+}
+
+func TestComplete(t *testing.T) {
+	s := newSession(&strings.Builder{})
+	s.run("let counter = 1; let count = 2; let total = 3;")
+
+	tests := []struct {
+		line     string
+		pos      int // -1 for the end of line
+		start    int
+		expected string
+	}{
+		{"cou", -1, 0, "count counter"},
+		{"1 + to", -1, 4, "total"},
+		{"re", -1, 0, "return"},
+		{"let x = f", -1, 8, "false finally fn for"},
+		{"wh", -1, 0, "while"},
+		{"count + x", 5, 0, "count counter"},
+		{"x + ", -1, 4, "break catch continue count counter else false finally fn for if in let macro return throw total true try while"},
+		{"\"co", -1, 3, ""},
+		{"12", -1, 2, ""},
+		{"\"a\" + t", -1, 6, "throw total true try"},
+		{":re", -1, 0, ":reset"},
+		{":", -1, 0, ":ast :dot :env :eval :help :load :reset :sexpr :time :tokens"},
+		{"étot", -1, 1, "total"},
+	}
+
+	for _, tt := range tests {
+		line := []rune(tt.line)
+		pos := tt.pos
+		if pos < 0 {
+			pos = len(line)
+		}
+
+		start, candidates := s.complete(line, pos)
+		if tt.expected == "" && len(candidates) == 0 {
+			continue
+		}
+
+		if start != tt.start || strings.Join(candidates, " ") != tt.expected {
+			t.Fatalf("complete(%q, %d) expected=%d %q, got=%d %q", tt.line, pos, tt.start, tt.expected, start, candidates)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd)
+
+package repl
+
+import "errors"
+
+// line editing needs raw mode, which is not implemented here, input is
+// read a line at a time and terminals can only be guessed at
+
+const canDetectTerminal = false
+
+func isTerminal(fd int) bool {
+	return false
+}
+
+func makeRaw(fd int) (restore func(), err error) {
+	return nil, errors.New("raw mode is not supported on this platform")
+}
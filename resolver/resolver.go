@@ -0,0 +1,148 @@
+// Package resolver works out what every identifier of a program refers to.
+//
+// Resolve builds a symbol table for the program and one for each function
+// literal in it, and sets the Symbol of every ast.Identifier to the global,
+// local, parameter, free or builtin symbol it names. Monkey has no block
+// scope, names declared inside an if or a loop belong to the enclosing
+// function. Functions declared at the top level are resolved after the
+// rest of the program, so they see every global, even later ones.
+//
+// Macro bodies are left alone, they are templates and their identifiers
+// only mean something once expanded, so resolve programs after macro
+// expansion.
+package resolver
+
+import (
+	"fmt"
+	"interpreter/ast"
+	"interpreter/token"
+	"sort"
+)
+
+// Error is an identifier that could not be resolved
+type Error struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+type resolver struct {
+	table  *SymbolTable
+	errors []Error
+
+	// functions declared in the global scope, resolved once every global
+	// is defined
+	deferred []*ast.FunctionLiteral
+}
+
+// Resolve resolves the identifiers of program. builtins are the names the
+// runtime predeclares, each in the slot of its index. It returns the global
+// symbol table along with the undefined names found.
+func Resolve(program *ast.Program, builtins []string) (*SymbolTable, []Error) {
+	r := &resolver{table: NewSymbolTable()}
+
+	for i, name := range builtins {
+		r.table.DefineBuiltin(i, name)
+	}
+
+	r.resolve(program)
+
+	// a function body runs once the whole program has, so it can refer to
+	// globals declared after it, like mutually recursive functions do
+	for _, fn := range r.deferred {
+		r.function(fn)
+	}
+
+	sort.SliceStable(r.errors, func(i, j int) bool {
+		return r.errors[i].Pos.Offset < r.errors[j].Pos.Offset
+	})
+
+	return r.table, r.errors
+}
+
+func (r *resolver) resolve(node ast.Node) {
+	ast.Inspect(node, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.LetStatement:
+			// a function can call itself, so its name is defined before
+			// the body; any other value still sees the previous binding
+			if _, ok := n.Value.(*ast.FunctionLiteral); ok {
+				r.define(n.Name)
+				r.resolveExpression(n.Value)
+			} else {
+				r.resolveExpression(n.Value)
+				r.define(n.Name)
+			}
+			return false
+
+		case *ast.ForStatement:
+			r.resolveExpression(n.Iterable)
+			r.define(n.Variable)
+			if n.Body != nil {
+				r.resolve(n.Body)
+			}
+			return false
+
+		case *ast.TryStatement:
+			// like a loop variable, the caught value is bound in the
+			// enclosing scope, blocks have no scope of their own
+			if n.Block != nil {
+				r.resolve(n.Block)
+			}
+			r.define(n.CatchParameter)
+			if n.Catch != nil {
+				r.resolve(n.Catch)
+			}
+			if n.Finally != nil {
+				r.resolve(n.Finally)
+			}
+			return false
+
+		case *ast.FunctionLiteral:
+			if r.table.Outer == nil {
+				r.deferred = append(r.deferred, n)
+			} else {
+				r.function(n)
+			}
+			return false
+
+		case *ast.MacroLiteral:
+			return false
+
+		case *ast.Identifier:
+			symbol, ok := r.table.Resolve(n.Value)
+			if !ok {
+				r.errors = append(r.errors, Error{Pos: n.Token.Pos, Msg: fmt.Sprintf("undefined: %s", n.Value)})
+			}
+			n.Symbol = symbol
+		}
+
+		return true
+	})
+}
+
+func (r *resolver) function(fn *ast.FunctionLiteral) {
+	r.table = NewEnclosedSymbolTable(r.table, fn)
+	for _, param := range fn.Parameters {
+		param.Symbol = r.table.DefineParameter(param)
+	}
+	if fn.Body != nil {
+		r.resolve(fn.Body)
+	}
+	r.table = r.table.Outer
+}
+
+func (r *resolver) resolveExpression(exp ast.Expression) {
+	if exp != nil {
+		r.resolve(exp)
+	}
+}
+
+func (r *resolver) define(name *ast.Identifier) {
+	if name != nil {
+		name.Symbol = r.table.Define(name)
+	}
+}
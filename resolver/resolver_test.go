@@ -0,0 +1,152 @@
+package resolver
+
+import (
+	"fmt"
+	"interpreter/ast"
+	"interpreter/lexer"
+	"interpreter/parser"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string // every identifier in source order as name:scope:index
+	}{
+		{
+			"let a = 1; let b = a + len(a);",
+			"a:GLOBAL:0 b:GLOBAL:1 a:GLOBAL:0 len:BUILTIN:1 a:GLOBAL:0",
+		},
+		{
+			"let f = fn(x, y) { let z = x + y; z };",
+			"f:GLOBAL:0 x:PARAMETER:0 y:PARAMETER:1 z:LOCAL:2 x:PARAMETER:0 y:PARAMETER:1 z:LOCAL:2",
+		},
+		{
+			"let f = fn(n) { f(n) };",
+			"f:GLOBAL:0 n:PARAMETER:0 f:GLOBAL:0 n:PARAMETER:0",
+		},
+		{
+			"fn(a) { let b = 1; fn(c) { fn() { a + b + c } } };",
+			"a:PARAMETER:0 b:LOCAL:1 c:PARAMETER:0 a:FREE:0 b:FREE:1 c:FREE:2",
+		},
+		{
+			"let xs = 1; for (x in xs) { if (x) { let y = x; } y; }",
+			"xs:GLOBAL:0 x:GLOBAL:1 xs:GLOBAL:0 x:GLOBAL:1 y:GLOBAL:2 x:GLOBAL:1 y:GLOBAL:2",
+		},
+		{
+			"fn(a) { try { throw a; } catch (e) { a + e } finally { e } };",
+			"a:PARAMETER:0 a:PARAMETER:0 e:LOCAL:1 a:PARAMETER:0 e:LOCAL:1 e:LOCAL:1",
+		},
+		{
+			"let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } }; let odd = fn(n) { !even(n) };",
+			"even:GLOBAL:0 n:PARAMETER:0 n:PARAMETER:0 odd:GLOBAL:1 n:PARAMETER:0 odd:GLOBAL:1 n:PARAMETER:0 even:GLOBAL:0 n:PARAMETER:0",
+		},
+		{
+			"let x = 1; let x = x + 1;",
+			"x:GLOBAL:0 x:GLOBAL:1 x:GLOBAL:0",
+		},
+		{
+			"let m = macro(x) { quote(unquote(x) + y) }; m(1);",
+			"m:GLOBAL:0 m:GLOBAL:0",
+		},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+
+		_, errs := Resolve(program, []string{"puts", "len"})
+		if len(errs) != 0 {
+			t.Fatalf("unexpected errors for %q: %v", tt.input, errs)
+		}
+
+		var got []string
+		ast.Inspect(program, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.MacroLiteral:
+				return false
+			case *ast.Identifier:
+				got = append(got, fmt.Sprintf("%s:%s:%d", n.Value, n.Symbol.Scope, n.Symbol.Index))
+			}
+			return true
+		})
+
+		if strings.Join(got, " ") != tt.expected {
+			t.Fatalf("wrong symbols for %q\nexpected=%q\ngot=%q", tt.input, tt.expected, strings.Join(got, " "))
+		}
+	}
+}
+
+func TestFreeSymbols(t *testing.T) {
+	program := parse(t, "fn(a) { fn(b) { fn() { a + b } } };")
+
+	global, _ := Resolve(program, nil)
+
+	outer := global.Inner[0]
+	middle := outer.Inner[0]
+	inner := middle.Inner[0]
+
+	tests := []struct {
+		table    *SymbolTable
+		expected string
+	}{
+		{outer, ""},
+		{middle, "a:PARAMETER:0"},
+		{inner, "a:FREE:0 b:PARAMETER:0"},
+	}
+
+	for i, tt := range tests {
+		var got []string
+		for _, symbol := range tt.table.Free {
+			got = append(got, fmt.Sprintf("%s:%s:%d", symbol.Name, symbol.Scope, symbol.Index))
+		}
+
+		if strings.Join(got, " ") != tt.expected {
+			t.Fatalf("tests[%d] - wrong free symbols, expected=%q, got=%q", i, tt.expected, strings.Join(got, " "))
+		}
+	}
+
+	if inner.Outer != middle || inner.Node == nil || global.Node != nil {
+		t.Fatalf("symbol tables are not nested")
+	}
+}
+
+func TestUndefinedIdentifiers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"x;", []string{"1:1: undefined: x"}},
+		{"let a = b;\nc = a;", []string{"1:9: undefined: b", "2:1: undefined: c"}},
+		{"fn(x) { let y = 1; }; x + y;", []string{"1:23: undefined: x", "1:27: undefined: y"}},
+		{"for (x in xs) { }", []string{"1:11: undefined: xs"}},
+		{"let a = 1; a; len(a);", []string{"1:15: undefined: len"}},
+		{"let y = y;", []string{"1:9: undefined: y"}},
+		{"let f = fn() { g() + h }; let g = fn() { 1 }; k;", []string{"1:22: undefined: h", "1:47: undefined: k"}},
+	}
+
+	for _, tt := range tests {
+		_, errs := Resolve(parse(t, tt.input), nil)
+
+		var got []string
+		for _, err := range errs {
+			got = append(got, err.Error())
+		}
+
+		if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+			t.Fatalf("wrong errors for %q\nexpected=%q\ngot=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+
+	return program
+}
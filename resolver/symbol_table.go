@@ -0,0 +1,104 @@
+package resolver
+
+import "interpreter/ast"
+
+// A SymbolTable holds the names declared in one scope, the program itself or
+// the body of a function literal
+type SymbolTable struct {
+	Outer *SymbolTable
+	Node  ast.Node // the function literal, nil for the global table
+	Inner []*SymbolTable
+
+	// Free are the symbols of enclosing functions used in this one, the
+	// FREE symbol with index i refers to Free[i]
+	Free []*ast.Symbol
+
+	store          map[string]*ast.Symbol
+	symbols        []*ast.Symbol
+	numDefinitions int
+}
+
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{store: map[string]*ast.Symbol{}}
+}
+
+func NewEnclosedSymbolTable(outer *SymbolTable, node ast.Node) *SymbolTable {
+	s := NewSymbolTable()
+	s.Outer = outer
+	s.Node = node
+	outer.Inner = append(outer.Inner, s)
+	return s
+}
+
+// Define declares name in s, a name declared again gets a new slot
+func (s *SymbolTable) Define(name *ast.Identifier) *ast.Symbol {
+	scope := ast.LocalScope
+	if s.Outer == nil {
+		scope = ast.GlobalScope
+	}
+	return s.define(name, scope)
+}
+
+// DefineParameter declares name as a parameter of the function of s
+func (s *SymbolTable) DefineParameter(name *ast.Identifier) *ast.Symbol {
+	return s.define(name, ast.ParameterScope)
+}
+
+func (s *SymbolTable) define(name *ast.Identifier, scope ast.SymbolScope) *ast.Symbol {
+	symbol := &ast.Symbol{Name: name.Value, Scope: scope, Index: s.numDefinitions, Decl: name}
+	s.numDefinitions++
+	s.add(symbol)
+	return symbol
+}
+
+// DefineBuiltin declares a builtin name in slot index
+func (s *SymbolTable) DefineBuiltin(index int, name string) *ast.Symbol {
+	symbol := &ast.Symbol{Name: name, Scope: ast.BuiltinScope, Index: index}
+	s.add(symbol)
+	return symbol
+}
+
+func (s *SymbolTable) add(symbol *ast.Symbol) {
+	s.store[symbol.Name] = symbol
+	s.symbols = append(s.symbols, symbol)
+}
+
+// Resolve looks name up in s and the tables enclosing it. Locals and
+// parameters of enclosing functions become free symbols of every function
+// in between.
+func (s *SymbolTable) Resolve(name string) (*ast.Symbol, bool) {
+	if symbol, ok := s.store[name]; ok {
+		return symbol, true
+	}
+
+	if s.Outer == nil {
+		return nil, false
+	}
+
+	symbol, ok := s.Outer.Resolve(name)
+	if !ok || symbol.Scope == ast.GlobalScope || symbol.Scope == ast.BuiltinScope {
+		return symbol, ok
+	}
+
+	return s.defineFree(symbol), true
+}
+
+func (s *SymbolTable) defineFree(original *ast.Symbol) *ast.Symbol {
+	s.Free = append(s.Free, original)
+
+	symbol := &ast.Symbol{Name: original.Name, Scope: ast.FreeScope, Index: len(s.Free) - 1, Decl: original.Decl}
+	s.add(symbol)
+	return symbol
+}
+
+// Symbols returns the symbols of s in the order they were added, including
+// builtins and free symbols
+func (s *SymbolTable) Symbols() []*ast.Symbol {
+	return s.symbols
+}
+
+// NumDefinitions returns how many slots the globals, or the parameters and
+// locals of a function, take
+func (s *SymbolTable) NumDefinitions() int {
+	return s.numDefinitions
+}
@@ -0,0 +1,464 @@
+// Package typecheck checks the optional type annotations of Monkey programs.
+//
+// Names can be annotated with int, bool, string or fn, and functions with
+// the types of their parameters and result:
+//
+//	let x: int = 5;
+//	let f = fn(a: int, b: string): bool { ... };
+//
+// The checker infers the types of literals, operators and calls to
+// functions whose signature it knows. A let without an annotation takes the
+// type of its value unless it is assigned to later on. Everything else,
+// like unannotated parameters, is dynamic and is not checked, so programs
+// without annotations only get errors for operations that fail on literals.
+package typecheck
+
+import (
+	"fmt"
+	"interpreter/ast"
+	"interpreter/resolver"
+	"interpreter/token"
+)
+
+// Error is a type mismatch
+type Error struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+// Info holds the types found by Check
+type Info struct {
+	// Types maps every expression checked, including declared names, to its type
+	Types map[ast.Expression]Type
+}
+
+// function is the function literal being checked
+type function struct {
+	result  Type // the annotated result, nil when there is none
+	returns []Type
+}
+
+type checker struct {
+	info       *Info
+	errors     []Error
+	decls      map[*ast.Identifier]Type // type of every declared name
+	reassigned map[*ast.Identifier]bool
+	signatures map[*ast.FunctionLiteral]*Function
+	functions  []*function
+}
+
+// Check resolves the names of program and checks its types
+func Check(program *ast.Program) (*Info, []Error) {
+	c := &checker{
+		info:       &Info{Types: map[ast.Expression]Type{}},
+		decls:      map[*ast.Identifier]Type{},
+		reassigned: map[*ast.Identifier]bool{},
+		signatures: map[*ast.FunctionLiteral]*Function{},
+	}
+
+	// undefined names are the resolver's business, they are dynamic here
+	resolver.Resolve(program, nil)
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		if assign, ok := node.(*ast.AssignExpression); ok {
+			if ident, ok := assign.Target.(*ast.Identifier); ok && ident.Symbol != nil && ident.Symbol.Decl != nil {
+				c.reassigned[ident.Symbol.Decl] = true
+			}
+		}
+		return true
+	})
+
+	c.statements(program.Statements)
+
+	return c.info, c.errors
+}
+
+func (c *checker) errorf(pos token.Position, format string, args ...any) {
+	c.errors = append(c.errors, Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+// annotation returns the type named by tn, nil when there is no annotation
+func (c *checker) annotation(tn *ast.TypeName) Type {
+	if tn == nil {
+		return nil
+	}
+
+	t, ok := named[tn.Name]
+	if !ok {
+		c.errorf(tn.Pos(), "unknown type %s", tn.Name)
+		return Dynamic
+	}
+	return t
+}
+
+func (c *checker) declare(name *ast.Identifier, t Type) {
+	c.decls[name] = t
+	c.info.Types[name] = t
+}
+
+// statements checks stmts and returns the type of the value they produce
+func (c *checker) statements(stmts []ast.Statement) Type {
+	var t Type = Dynamic
+	for _, stmt := range stmts {
+		t = c.statement(stmt)
+	}
+	return t
+}
+
+func (c *checker) statement(stmt ast.Statement) Type {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		c.let(stmt)
+
+	case *ast.ReturnStatement:
+		c.returnStatement(stmt)
+
+	case *ast.ExpressionStatement:
+		return c.expression(stmt.Expression)
+
+	case *ast.BlockStatement:
+		return c.statements(stmt.Statements)
+
+	case *ast.WhileStatement:
+		c.expression(stmt.Condition)
+		c.block(stmt.Body)
+
+	case *ast.ForStatement:
+		c.expression(stmt.Iterable)
+		if stmt.Variable != nil {
+			c.declare(stmt.Variable, Dynamic)
+		}
+		c.block(stmt.Body)
+
+	case *ast.TryStatement:
+		c.block(stmt.Block)
+		if stmt.CatchParameter != nil {
+			// anything can be thrown
+			c.declare(stmt.CatchParameter, Dynamic)
+		}
+		c.block(stmt.Catch)
+		c.block(stmt.Finally)
+
+	case *ast.ThrowStatement:
+		c.expression(stmt.Value)
+	}
+
+	return Dynamic
+}
+
+func (c *checker) block(block *ast.BlockStatement) Type {
+	if block == nil {
+		return Dynamic
+	}
+	return c.statements(block.Statements)
+}
+
+func (c *checker) let(stmt *ast.LetStatement) {
+	if stmt.Name == nil {
+		return
+	}
+
+	declared := c.annotation(stmt.Name.Type)
+
+	// a function knows its own signature in its body
+	if fn, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		if declared != nil {
+			c.declare(stmt.Name, declared)
+		} else {
+			c.declare(stmt.Name, c.signature(fn))
+		}
+	}
+
+	value := c.expression(stmt.Value)
+
+	switch {
+	case declared != nil:
+		if !assignable(declared, value) {
+			c.errorf(stmt.Value.Pos(), "cannot use %s value as %s in let %s", value, declared, stmt.Name.Value)
+		}
+		c.declare(stmt.Name, declared)
+
+	case c.reassigned[stmt.Name]:
+		c.declare(stmt.Name, Dynamic)
+
+	default:
+		c.declare(stmt.Name, value)
+	}
+}
+
+func (c *checker) returnStatement(stmt *ast.ReturnStatement) {
+	if stmt.ReturnValue == nil {
+		return
+	}
+
+	t := c.expression(stmt.ReturnValue)
+	if len(c.functions) == 0 {
+		return
+	}
+
+	fn := c.functions[len(c.functions)-1]
+	fn.returns = append(fn.returns, t)
+
+	if fn.result != nil && !assignable(fn.result, t) {
+		c.errorf(stmt.ReturnValue.Pos(), "cannot return %s value from function returning %s", t, fn.result)
+	}
+}
+
+func (c *checker) expression(exp ast.Expression) Type {
+	if exp == nil {
+		return Dynamic
+	}
+
+	t := c.infer(exp)
+	c.info.Types[exp] = t
+	return t
+}
+
+func (c *checker) infer(exp ast.Expression) Type {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral:
+		return Int
+
+	case *ast.StringLiteral:
+		return String
+
+	case *ast.Boolean:
+		return Bool
+
+	case *ast.Identifier:
+		if exp.Symbol != nil && exp.Symbol.Decl != nil {
+			if t, ok := c.decls[exp.Symbol.Decl]; ok {
+				return t
+			}
+		}
+		return Dynamic
+
+	case *ast.PrefixExpression:
+		return c.prefix(exp)
+
+	case *ast.InfixExpression:
+		return c.infix(exp, exp.Operator, c.expression(exp.Left), c.expression(exp.Right))
+
+	case *ast.AssignExpression:
+		return c.assign(exp)
+
+	case *ast.IndexExpression:
+		c.expression(exp.Left)
+		c.expression(exp.Index)
+		return Dynamic
+
+	case *ast.IfExpression:
+		c.expression(exp.Condition)
+		consequence := c.block(exp.Consequence)
+		if exp.Alternative == nil {
+			return Dynamic
+		}
+		if alternative := c.block(exp.Alternative); consequence == alternative {
+			return consequence
+		}
+		return Dynamic
+
+	case *ast.CallExpression:
+		return c.call(exp)
+
+	case *ast.FunctionLiteral:
+		return c.function(exp)
+	}
+
+	return Dynamic
+}
+
+func (c *checker) prefix(exp *ast.PrefixExpression) Type {
+	right := c.expression(exp.Right)
+
+	switch exp.Operator {
+	case "-":
+		if right != Dynamic && right != Int {
+			c.errorf(exp.Pos(), "operator - not defined on %s", right)
+		}
+		return Int
+	case "!":
+		return Bool
+	}
+
+	return Dynamic
+}
+
+func (c *checker) infix(exp ast.Expression, operator string, left, right Type) Type {
+	mismatch := func() {
+		c.errorf(exp.Pos(), "mismatched types %s and %s in %s", left, right, exp)
+	}
+
+	// only int operands are allowed
+	ints := func(result Type) Type {
+		for _, t := range []Type{left, right} {
+			if t != Dynamic && t != Int {
+				c.errorf(exp.Pos(), "operator %s not defined on %s", operator, t)
+				break
+			}
+		}
+		return result
+	}
+
+	switch operator {
+	case "+":
+		switch {
+		case left == Dynamic:
+			return right
+		case right == Dynamic:
+			return left
+		case left != right:
+			mismatch()
+			return Dynamic
+		case left != Int && left != String:
+			c.errorf(exp.Pos(), "operator + not defined on %s", left)
+			return Dynamic
+		}
+		return left
+
+	case "-", "*", "/":
+		return ints(Int)
+
+	case "<", ">":
+		return ints(Bool)
+
+	case "==", "!=":
+		if left != Dynamic && right != Dynamic && !assignable(left, right) {
+			mismatch()
+		}
+		return Bool
+	}
+
+	return Dynamic
+}
+
+func (c *checker) assign(exp *ast.AssignExpression) Type {
+	target := c.expression(exp.Target)
+	value := c.expression(exp.Value)
+
+	if exp.Operator != "=" {
+		// x += y is checked as x + y
+		value = c.infix(exp, exp.Operator[:1], target, value)
+	}
+
+	if !assignable(target, value) {
+		c.errorf(exp.Value.Pos(), "cannot assign %s value to %s of type %s", value, exp.Target, target)
+	}
+
+	return target
+}
+
+func (c *checker) call(exp *ast.CallExpression) Type {
+	callee := c.expression(exp.Function)
+
+	args := []Type{}
+	for _, arg := range exp.Arguments {
+		args = append(args, c.expression(arg))
+	}
+
+	fn, ok := callee.(*Function)
+	if !ok {
+		if callee != Dynamic {
+			c.errorf(exp.Function.Pos(), "cannot call %s of type %s", exp.Function, callee)
+		}
+		return Dynamic
+	}
+
+	if fn.Params == nil {
+		return fn.Return
+	}
+
+	if len(args) != len(fn.Params) {
+		c.errorf(exp.Function.Pos(), "wrong number of arguments to %s, expected %d, got %d", exp.Function, len(fn.Params), len(args))
+		return fn.Return
+	}
+
+	for i, arg := range args {
+		if !assignable(fn.Params[i], arg) {
+			c.errorf(exp.Arguments[i].Pos(), "cannot use %s value as %s in argument %d to %s", arg, fn.Params[i], i+1, exp.Function)
+		}
+	}
+
+	return fn.Return
+}
+
+// signature returns the type of fn built from its annotations, the result
+// is completed by function once the body has been checked
+func (c *checker) signature(fn *ast.FunctionLiteral) *Function {
+	if sig, ok := c.signatures[fn]; ok {
+		return sig
+	}
+
+	sig := &Function{Params: []Type{}, Return: Dynamic}
+	for _, param := range fn.Parameters {
+		t := c.annotation(param.Type)
+		if t == nil {
+			t = Dynamic
+		}
+		sig.Params = append(sig.Params, t)
+	}
+
+	if result := c.annotation(fn.ReturnType); result != nil {
+		sig.Return = result
+	}
+
+	c.signatures[fn] = sig
+	return sig
+}
+
+func (c *checker) function(fn *ast.FunctionLiteral) Type {
+	sig := c.signature(fn)
+
+	for i, param := range fn.Parameters {
+		c.declare(param, sig.Params[i])
+	}
+
+	frame := &function{}
+	if fn.ReturnType != nil {
+		frame.result = sig.Return
+	}
+
+	c.functions = append(c.functions, frame)
+	last := c.block(fn.Body)
+	c.functions = c.functions[:len(c.functions)-1]
+
+	// the value of the last expression statement is returned too, any other
+	// last statement but a return makes the function return null
+	returns := frame.returns
+	if fn.Body != nil && len(fn.Body.Statements) > 0 {
+		switch stmt := fn.Body.Statements[len(fn.Body.Statements)-1].(type) {
+		case *ast.ExpressionStatement:
+			if frame.result != nil && !assignable(frame.result, last) {
+				c.errorf(stmt.Pos(), "cannot return %s value from function returning %s", last, frame.result)
+			}
+			returns = append(returns, last)
+		case *ast.ReturnStatement:
+		default:
+			returns = append(returns, Dynamic)
+		}
+	}
+
+	if frame.result == nil {
+		sig.Return = common(returns)
+	}
+
+	return sig
+}
+
+// common returns the type shared by all of types, Dynamic when they differ
+func common(types []Type) Type {
+	if len(types) == 0 {
+		return Dynamic
+	}
+
+	for _, t := range types[1:] {
+		if t != types[0] {
+			return Dynamic
+		}
+	}
+	return types[0]
+}
@@ -0,0 +1,111 @@
+package typecheck
+
+import (
+	"interpreter/ast"
+	"interpreter/lexer"
+	"interpreter/parser"
+	"strings"
+	"testing"
+)
+
+func TestCheckErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x: int = 5; let s: string = \"a\"; let b: bool = !x;", nil},
+		{"let x: int = \"five\";", []string{"1:14: cannot use string value as int in let x"}},
+		{"let x: number = 5;", []string{"1:8: unknown type number"}},
+		{"let x = 5; x + \"a\";", []string{"1:14: mismatched types int and string in (x + a)"}},
+		{"let x = 5; x = \"a\"; x + \"a\";", nil},
+		{"let x: int = 5; x = \"a\";", []string{"1:21: cannot assign string value to x of type int"}},
+		{"let x: int = 5; x += \"a\";", []string{"1:19: mismatched types int and string in (x += a)"}},
+		{"-true; 1 < \"a\"; true + false;", []string{
+			"1:1: operator - not defined on bool",
+			"1:10: operator < not defined on string",
+			"1:22: operator + not defined on bool",
+		}},
+		{"1 == true;", []string{"1:3: mismatched types int and bool in (1 == true)"}},
+		{"fn(a, b) { a + b * c }", nil},
+		{
+			"let f = fn(a: int, b: string): bool { a == 1 }; f(1, 2); f(1); let r: int = f(1, \"a\");",
+			[]string{
+				"1:54: cannot use int value as string in argument 2 to f",
+				"1:58: wrong number of arguments to f, expected 2, got 1",
+				"1:78: cannot use bool value as int in let r",
+			},
+		},
+		{"let f = fn(): int { return \"a\"; };", []string{"1:28: cannot return string value from function returning int"}},
+		{"let f = fn(): int { \"a\" };", []string{"1:21: cannot return string value from function returning int"}},
+		{"let f = fn(n: int): int { if (n < 1) { return 0; } f(n - 1) + 1 };", nil},
+		{"let f = fn(x) { x * 2 }; let n: int = 3; n(1);", []string{"1:42: cannot call n of type int"}},
+		{"let apply = fn(g: fn, x: int): int { g(x) }; apply(fn(a) { a }, 1); apply(1, 2);", []string{
+			"1:75: cannot use int value as fn in argument 1 to apply",
+		}},
+		{"let x: int = 1; try { throw x + \"a\"; } catch (e) { x = e; } finally { -\"s\" }", []string{
+			"1:31: mismatched types int and string in (x + a)",
+			"1:71: operator - not defined on string",
+		}},
+	}
+
+	for _, tt := range tests {
+		_, errs := Check(parse(t, tt.input))
+
+		var got []string
+		for _, err := range errs {
+			got = append(got, err.Error())
+		}
+
+		if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+			t.Fatalf("wrong errors for %q\nexpected=%q\ngot=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestInference(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string // type of the last expression statement
+	}{
+		{"2 * 3;", "int"},
+		{"\"a\" + \"b\";", "string"},
+		{"1 < 2;", "bool"},
+		{"let x = 5; x;", "int"},
+		{"let x = 5; x = true; x;", "dynamic"},
+		{"if (x) { 1 } else { 2 };", "int"},
+		{"if (x) { 1 } else { \"a\" };", "dynamic"},
+		{"if (x) { 1 };", "dynamic"},
+		{"fn(a: int, b) { a };", "fn(int, dynamic): int"},
+		{"fn(a) { if (a) { return 1; } 2 };", "fn(dynamic): int"},
+		{"fn(a) { if (a) { return 1; } let b = 2; };", "fn(dynamic): dynamic"},
+		{"let f = fn(a: string): bool { true }; f(\"a\");", "bool"},
+		{"let f = fn(a) { a + 1 }; f(1);", "int"},
+		{"x(1);", "dynamic"},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+
+		info, errs := Check(program)
+		if len(errs) != 0 {
+			t.Fatalf("unexpected errors for %q: %v", tt.input, errs)
+		}
+
+		last := program.Statements[len(program.Statements)-1].(*ast.ExpressionStatement)
+		if got := info.Types[last.Expression].String(); got != tt.expected {
+			t.Fatalf("wrong type for %q, expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+
+	return program
+}
@@ -0,0 +1,86 @@
+package typecheck
+
+import "strings"
+
+// A Type is what the checker knows about the values of an expression
+type Type interface {
+	String() string
+}
+
+// Basic is one of the named types
+type Basic string
+
+const (
+	Int    Basic = "int"
+	Bool   Basic = "bool"
+	String Basic = "string"
+
+	// Dynamic is the type of unannotated code, anything goes
+	Dynamic Basic = "dynamic"
+)
+
+func (b Basic) String() string { return string(b) }
+
+// Function is the type of a function literal. Params is nil for the fn
+// annotation, which stands for any function.
+type Function struct {
+	Params []Type
+	Return Type
+}
+
+func (f *Function) String() string {
+	if f.Params == nil {
+		return "fn"
+	}
+
+	params := []string{}
+	for _, p := range f.Params {
+		params = append(params, p.String())
+	}
+
+	return "fn(" + strings.Join(params, ", ") + "): " + f.Return.String()
+}
+
+// anyFunction is the type named by the fn annotation
+var anyFunction = &Function{Return: Dynamic}
+
+var named = map[string]Type{
+	"int":    Int,
+	"bool":   Bool,
+	"string": String,
+	"fn":     anyFunction,
+}
+
+// assignable reports whether a value of type from can be used where a to is
+// expected, dynamic values can be used anywhere and anything can be used
+// where a dynamic value is expected
+func assignable(to, from Type) bool {
+	if to == Dynamic || from == Dynamic {
+		return true
+	}
+
+	switch to := to.(type) {
+	case Basic:
+		return to == from
+
+	case *Function:
+		from, ok := from.(*Function)
+		if !ok {
+			return false
+		}
+		if to.Params == nil || from.Params == nil {
+			return true
+		}
+		if len(to.Params) != len(from.Params) {
+			return false
+		}
+		for i := range to.Params {
+			if !assignable(from.Params[i], to.Params[i]) {
+				return false
+			}
+		}
+		return assignable(to.Return, from.Return)
+	}
+
+	return false
+}